@@ -1,164 +1,91 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
-	"os"
-)
 
-func createShopifyOrder(order Order) error {
-	storeName := os.Getenv("STORE_NAME")
-	shopifyToken := os.Getenv("SHOPIFY_TOKEN")
-	shopifyURL := fmt.Sprintf("https://%s.myshopify.com/admin/api/2021-07/orders.json", storeName)
+	"mokobara-middleware/shared/shopify"
+)
 
-	shopifyLineItems := []ShopifyLineItem{}
+// toShopifyOrder maps a Magento order onto a Shopify order. Tags are left
+// empty, and so omitted, because an update would replace the tags merchants
+// added in Shopify.
+func toShopifyOrder(order Order, fulfillmentStatus string) shopify.Order {
+	shopifyLineItems := []shopify.LineItem{}
 
 	for _, item := range order.Items {
-		shopifyLineItems = append(shopifyLineItems, ShopifyLineItem{
+		shopifyLineItems = append(shopifyLineItems, shopify.LineItem{
 			Title:    item.Name,
 			Quantity: item.Quantity,
 			Price:    fmt.Sprintf("%.2f", item.Price),
 		})
 	}
 
-	shopifyOrder := ShopifyOrder{
-		Order: ShopifyOrderDetails{
-			Email:       order.CustomerEmail,
-			Fulfillment: "unfulfilled",
-			LineItems:   shopifyLineItems,
-			ShippingAddress: ShopifyShippingAddress{
-				FirstName: order.Shipping.Firstname,
-				LastName:  order.Shipping.Lastname,
-				Address1:  order.Shipping.Street,
-				City:      order.Shipping.City,
-				Province:  order.Shipping.Region,
-				Zip:       order.Shipping.Postcode,
-				Country:   order.Shipping.CountryID,
-			},
-			BillingAddress: ShopifyBillingAddress{
-				FirstName: order.Billing.Firstname,
-				LastName:  order.Billing.Lastname,
-				Address1:  order.Billing.Street,
-				City:      order.Billing.City,
-				Province:  order.Billing.Region,
-				Zip:       order.Billing.Postcode,
-				Country:   order.Billing.CountryID,
-			},
-		},
+	return shopify.Order{
+		Email:             order.CustomerEmail,
+		FulfillmentStatus: fulfillmentStatus,
+		LineItems:         shopifyLineItems,
+		ShippingAddress:   toShopifyAddress(order.Shipping),
+		BillingAddress:    toShopifyAddress(order.Billing),
 	}
+}
 
-	shopifyOrderJSON, err := json.Marshal(shopifyOrder)
-	if err != nil {
-		return fmt.Errorf("❌ failed to marshal Shopify order: %v", err)
+func toShopifyAddress(address Address) shopify.Address {
+	return shopify.Address{
+		FirstName: address.Firstname,
+		LastName:  address.Lastname,
+		Address1:  address.Street,
+		City:      address.City,
+		Province:  address.Region,
+		Zip:       address.Postcode,
+		Country:   address.CountryID,
+		Phone:     address.Telephone,
 	}
+}
 
-	// print the order JSON
-	fmt.Printf("🔥 Shopify Order JSON: %s\n", shopifyOrderJSON)
-
-	// create a new HTTP request
-	req, err := http.NewRequest("POST", shopifyURL, bytes.NewBuffer(shopifyOrderJSON))
-
+func createShopifyOrder(ctx context.Context, order Order) (*shopify.Order, error) {
+	client, err := getShopifyClient()
 	if err != nil {
-		return fmt.Errorf("❌ failed to create Shopify request: %v", err)
+		return nil, fmt.Errorf("failed to configure Shopify client: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Shopify-Access-Token", shopifyToken)
+	shopifyOrder := toShopifyOrder(order, "unfulfilled")
+	// Tag the order with the Magento order ID so later pushes can find it.
+	// Only new orders are tagged; the tag stays on through updates.
+	shopifyOrder.Tags = order.OrderID
 
-	// send the request
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("❌ failed to send Shopify request: %v", err)
-	}
+	fmt.Printf("🔥 Creating Shopify order for %s\n", order.OrderID)
 
-	// check the response status code
-	if res.StatusCode != http.StatusCreated {
-		return fmt.Errorf("❌ unexpected status code: %d", res.StatusCode)
+	created, err := client.CreateOrder(ctx, shopifyOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Shopify order: %w", err)
 	}
 
-	defer res.Body.Close()
-
-	return nil
+	fmt.Printf("✅ Shopify order created: %s (%d)\n", created.Name, created.ID)
+	return created, nil
 }
 
-func updateShopifyOrder(order Order) error {
-	storeName := os.Getenv("STORE_NAME")
-	shopifyToken := os.Getenv("SHOPIFY_TOKEN")
-	shopifyURL := fmt.Sprintf("https://%s.myshopify.com/admin/api/2021-07/orders/%s.json", storeName, order.OrderID)
-
-	status := getOrderStatus(order.OrderID)
-
-	if status == "" {
-		return fmt.Errorf("status not found for order: %s", order.OrderID)
+func updateShopifyOrder(ctx context.Context, order Order, shopifyOrderID int64) (*shopify.Order, error) {
+	client, err := getShopifyClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure Shopify client: %w", err)
 	}
 
 	// get the status of the order
-
-	shopifyLineItems := []ShopifyLineItem{}
-
-	for _, item := range order.Items {
-		shopifyLineItems = append(shopifyLineItems, ShopifyLineItem{
-			Title:    item.Name,
-			Quantity: item.Quantity,
-			Price:    fmt.Sprintf("%.2f", item.Price),
-		})
-	}
-
-	shopifyOrder := ShopifyOrder{
-		Order: ShopifyOrderDetails{
-			Email:       order.CustomerEmail,
-			Fulfillment: status,
-			LineItems:   shopifyLineItems,
-			ShippingAddress: ShopifyShippingAddress{
-				FirstName: order.Shipping.Firstname,
-				LastName:  order.Shipping.Lastname,
-				Address1:  order.Shipping.Street,
-				City:      order.Shipping.City,
-				Province:  order.Shipping.Postcode,
-				Country:   order.Shipping.CountryID,
-			},
-			BillingAddress: ShopifyBillingAddress{
-				FirstName: order.Billing.Firstname,
-				LastName:  order.Billing.Lastname,
-				Address1:  order.Billing.Street,
-				City:      order.Billing.City,
-				Province:  order.Billing.Postcode,
-				Country:   order.Billing.CountryID,
-			},
-		},
-	}
-
-	shopifyOrderJSON, err := json.Marshal(shopifyOrder)
+	status, err := getOrderStatus(ctx, order.OrderID)
 	if err != nil {
-		return fmt.Errorf("❌ failed to marshal Shopify order: %v", err)
+		return nil, fmt.Errorf("failed to get status for order %s: %w", order.OrderID, err)
 	}
 
-	// print the order JSON
-	fmt.Printf("🔥 Shopify Order JSON: %s\n", shopifyOrderJSON)
-
-	// create a new HTTP request to update the order
-	req, err := http.NewRequest("PUT", shopifyURL, bytes.NewBuffer(shopifyOrderJSON))
-	if err != nil {
-		return fmt.Errorf("❌ failed to create Shopify request: %v", err)
-	}
+	shopifyOrder := toShopifyOrder(order, status)
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Shopify-Access-Token", shopifyToken)
+	fmt.Printf("🔥 Updating Shopify order %d for %s (%s)\n", shopifyOrderID, order.OrderID, status)
 
-	// send the request
-	res, err := http.DefaultClient.Do(req)
+	updated, err := client.UpdateOrder(ctx, shopifyOrderID, shopifyOrder)
 	if err != nil {
-		return fmt.Errorf("❌ failed to send Shopify request: %v", err)
+		return nil, fmt.Errorf("failed to update Shopify order: %w", err)
 	}
 
-	// check the response status code
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("❌ unexpected status code: %d", res.StatusCode)
-	}
-
-	defer res.Body.Close()
-
-	return nil
+	fmt.Printf("✅ Shopify order updated: %s (%d)\n", updated.Name, updated.ID)
+	return updated, nil
 }
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestToShopifyOrderLeavesTagsOut(t *testing.T) {
+	order := Order{
+		OrderID:       "100000001",
+		CustomerEmail: "a@example.com",
+		Items:         []Item{{Name: "Carry-on", Quantity: 1, Price: 99.5}},
+	}
+
+	shopifyOrder := toShopifyOrder(order, "unfulfilled")
+	if shopifyOrder.Tags != "" {
+		t.Errorf("Tags = %q, want empty so updates keep merchant tags", shopifyOrder.Tags)
+	}
+
+	body, err := json.Marshal(shopifyOrder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), `"tags"`) {
+		t.Errorf("update payload %s must not carry tags", body)
+	}
+	if shopifyOrder.LineItems[0].Price != "99.50" {
+		t.Errorf("price = %q, want 99.50", shopifyOrder.LineItems[0].Price)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"mokobara-middleware/shared/apigw"
+)
+
+const (
+	signatureHeader = "X-Magento-Signature"
+	timestampHeader = "X-Magento-Timestamp"
+
+	// defaultSignatureTolerance is how far a signed request's timestamp may
+	// drift from now before it is treated as a replay.
+	defaultSignatureTolerance = 5 * time.Minute
+)
+
+// AuthError describes a rejected request and the status it should be answered with.
+type AuthError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func unauthorized(code, message string) *AuthError {
+	return &AuthError{StatusCode: http.StatusUnauthorized, Code: code, Message: message}
+}
+
+func forbidden(code, message string) *AuthError {
+	return &AuthError{StatusCode: http.StatusForbidden, Code: code, Message: message}
+}
+
+// RequestAuthenticator checks that an inbound order push came from Magento.
+type RequestAuthenticator interface {
+	Authenticate(request events.APIGatewayV2HTTPRequest, body []byte) error
+}
+
+// BearerTokenAuthenticator accepts requests carrying a shared bearer token.
+type BearerTokenAuthenticator struct {
+	Token string
+}
+
+func (a BearerTokenAuthenticator) Authenticate(request events.APIGatewayV2HTTPRequest, body []byte) error {
+	header := apigw.Header(request, "Authorization")
+	if header == "" {
+		return unauthorized("missing_credentials", "Authorization header is required")
+	}
+
+	// The auth scheme is case-insensitive (RFC 7235).
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return unauthorized("invalid_credentials", "Authorization header must use the Bearer scheme")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(a.Token)) != 1 {
+		return forbidden("invalid_token", "bearer token is not valid")
+	}
+
+	return nil
+}
+
+// HMACAuthenticator accepts requests whose body is signed with a shared secret.
+// The signature is the hex-encoded HMAC-SHA256 of "<timestamp>.<body>", so a
+// captured request cannot be replayed once its timestamp falls outside Tolerance.
+type HMACAuthenticator struct {
+	Secret    string
+	Tolerance time.Duration
+	Now       func() time.Time
+}
+
+func (a HMACAuthenticator) Authenticate(request events.APIGatewayV2HTTPRequest, body []byte) error {
+	signature := apigw.Header(request, signatureHeader)
+	timestamp := apigw.Header(request, timestampHeader)
+	if signature == "" || timestamp == "" {
+		return unauthorized("missing_signature", fmt.Sprintf("%s and %s headers are required", signatureHeader, timestampHeader))
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return unauthorized("invalid_timestamp", fmt.Sprintf("%s must be a unix timestamp", timestampHeader))
+	}
+
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	tolerance := a.Tolerance
+	if tolerance == 0 {
+		tolerance = defaultSignatureTolerance
+	}
+
+	drift := now().Sub(time.Unix(seconds, 0))
+	if drift < -tolerance || drift > tolerance {
+		return forbidden("stale_request", "request timestamp is outside the allowed window")
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return unauthorized("invalid_signature", "signature must be hex-encoded")
+	}
+
+	mac := hmac.New(sha256.New, []byte(a.Secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return forbidden("invalid_signature", "signature does not match request body")
+	}
+
+	return nil
+}
+
+// newAuthenticatorFromEnv builds the authenticator selected by ORDER_AUTH_MODE.
+func newAuthenticatorFromEnv() (RequestAuthenticator, error) {
+	mode := os.Getenv("ORDER_AUTH_MODE")
+	switch mode {
+	case "", "bearer":
+		token := os.Getenv("ORDER_AUTH_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("ORDER_AUTH_TOKEN environment variable not set")
+		}
+		return BearerTokenAuthenticator{Token: token}, nil
+
+	case "hmac":
+		secret := os.Getenv("ORDER_HMAC_SECRET")
+		if secret == "" {
+			return nil, fmt.Errorf("ORDER_HMAC_SECRET environment variable not set")
+		}
+		return HMACAuthenticator{Secret: secret}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown ORDER_AUTH_MODE: %s", mode)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func requestWithHeaders(headers map[string]string) events.APIGatewayV2HTTPRequest {
+	return events.APIGatewayV2HTTPRequest{Headers: headers}
+}
+
+// wantStatus checks that err is nil for status 0, or an *AuthError with status.
+func wantStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	if status == 0 {
+		if err != nil {
+			t.Fatalf("Authenticate() error = %v, want nil", err)
+		}
+		return
+	}
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("Authenticate() error = %v, want *AuthError", err)
+	}
+	if authErr.StatusCode != status {
+		t.Errorf("status = %d, want %d (%v)", authErr.StatusCode, status, authErr)
+	}
+}
+
+func TestBearerTokenAuthenticator(t *testing.T) {
+	auth := BearerTokenAuthenticator{Token: "s3cret"}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"valid token", map[string]string{"Authorization": "Bearer s3cret"}, 0},
+		{"lowercased header", map[string]string{"authorization": "Bearer s3cret"}, 0},
+		{"lowercased scheme", map[string]string{"Authorization": "bearer s3cret"}, 0},
+		{"uppercased scheme", map[string]string{"Authorization": "BEARER s3cret"}, 0},
+		{"scheme without token", map[string]string{"Authorization": "Bearer"}, http.StatusUnauthorized},
+		{"missing header", map[string]string{}, http.StatusUnauthorized},
+		{"wrong scheme", map[string]string{"Authorization": "Basic s3cret"}, http.StatusUnauthorized},
+		{"wrong token", map[string]string{"Authorization": "Bearer nope"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantStatus(t, auth.Authenticate(requestWithHeaders(tt.headers), nil), tt.want)
+		})
+	}
+}
+
+func TestHMACAuthenticator(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	auth := HMACAuthenticator{Secret: "s3cret", Now: func() time.Time { return now }}
+	body := []byte(`{"order_id":"100000001"}`)
+
+	signHMAC := func(timestamp int64, body []byte, secret string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+		mac.Write(body)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	headers := func(timestamp int64, signature string) map[string]string {
+		return map[string]string{
+			signatureHeader: signature,
+			timestampHeader: strconv.FormatInt(timestamp, 10),
+		}
+	}
+
+	ts := now.Unix()
+	tests := []struct {
+		name    string
+		headers map[string]string
+		body    []byte
+		want    int
+	}{
+		{"valid signature", headers(ts, signHMAC(ts, body, "s3cret")), body, 0},
+		{"tampered body", headers(ts, signHMAC(ts, body, "s3cret")), []byte(`{"order_id":"2"}`), http.StatusForbidden},
+		{"wrong secret", headers(ts, signHMAC(ts, body, "other")), body, http.StatusForbidden},
+		{"stale timestamp", headers(ts-3600, signHMAC(ts-3600, body, "s3cret")), body, http.StatusForbidden},
+		{"missing headers", map[string]string{}, body, http.StatusUnauthorized},
+		{"bad timestamp", map[string]string{signatureHeader: "00", timestampHeader: "yesterday"}, body, http.StatusUnauthorized},
+		{"signature not hex", headers(ts, "zz"), body, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantStatus(t, auth.Authenticate(requestWithHeaders(tt.headers), tt.body), tt.want)
+		})
+	}
+}
+
+func TestNewAuthenticatorFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{"bearer default", map[string]string{"ORDER_AUTH_TOKEN": "t"}, false},
+		{"bearer without token", map[string]string{}, true},
+		{"hmac", map[string]string{"ORDER_AUTH_MODE": "hmac", "ORDER_HMAC_SECRET": "s"}, false},
+		{"hmac without secret", map[string]string{"ORDER_AUTH_MODE": "hmac"}, true},
+		{"unknown mode", map[string]string{"ORDER_AUTH_MODE": "basic"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"ORDER_AUTH_MODE", "ORDER_AUTH_TOKEN", "ORDER_HMAC_SECRET"} {
+				t.Setenv(name, tt.env[name])
+			}
+			_, err := newAuthenticatorFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newAuthenticatorFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
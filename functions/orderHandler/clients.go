@@ -0,0 +1,20 @@
+package main
+
+import (
+	"sync"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/shopify"
+)
+
+// getMagentoClient and getShopifyClient return the clients shared by every
+// invocation of this Lambda instance, creating them on first use.
+var (
+	getMagentoClient = sync.OnceValues(magento.NewFromEnv)
+	getShopifyClient = sync.OnceValues(shopify.NewFromEnv)
+)
+
+// getMappingStore returns the Magento to Shopify mapping store selected by
+// MAPPING_STORE.
+var getMappingStore = sync.OnceValues(mapping.NewFromEnv)
@@ -3,10 +3,17 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+
+	"mokobara-middleware/shared/apigw"
+	"mokobara-middleware/shared/deadline"
+	"mokobara-middleware/shared/shopify"
 )
 
 // Order represents the structure of the incoming order payload.
@@ -39,86 +46,115 @@ type Item struct {
 	RowTotal float64 `json:"row_total"`
 }
 
-// ShopifyOrder represents the payload structure for Shopify.
-type ShopifyOrder struct {
-	Order ShopifyOrderDetails `json:"order"`
-}
-
-// ShopifyOrderDetails contains order details for Shopify.
-type ShopifyOrderDetails struct {
-	Email           string                 `json:"email"`
-	Fulfillment     string                 `json:"fulfillment_status"`
-	LineItems       []ShopifyLineItem      `json:"line_items"`
-	ShippingAddress ShopifyShippingAddress `json:"shipping_address"`
-	BillingAddress  ShopifyBillingAddress  `json:"billing_address"`
-}
-
-// ShopifyLineItem represents an order item for Shopify.
-type ShopifyLineItem struct {
-	Title    string `json:"title"`
-	Quantity int    `json:"quantity"`
-	Price    string `json:"price"`
-}
+// Validate checks the fields needed to create a Shopify order.
+func (o Order) Validate() error {
+	verr := &shopify.ValidationError{}
 
-// ShopifyShippingAddress represents the shipping address for Shopify.
-type ShopifyShippingAddress struct {
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Address1  string `json:"address1"`
-	City      string `json:"city"`
-	Province  string `json:"province"`
-	Country   string `json:"country"`
-	Zip       string `json:"zip"`
-	Phone     string `json:"phone"`
-}
+	if o.OrderID == "" {
+		verr.Fields = append(verr.Fields, shopify.FieldError{Field: "order_id", Problem: "is required"})
+	}
+	if len(o.Items) == 0 {
+		verr.Fields = append(verr.Fields, shopify.FieldError{Field: "items", Problem: "must contain at least one item"})
+	}
+	for i, item := range o.Items {
+		if item.Quantity <= 0 {
+			verr.Fields = append(verr.Fields, shopify.FieldError{Field: fmt.Sprintf("items[%d].qty", i), Problem: "must be positive"})
+		}
+	}
 
-// ShopifyBillingAddress represents the billing address for Shopify.
-type ShopifyBillingAddress struct {
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Address1  string `json:"address1"`
-	City      string `json:"city"`
-	Province  string `json:"province"`
-	Country   string `json:"country"`
-	Zip       string `json:"zip"`
-	Phone     string `json:"phone"`
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
 }
 
 // HandleOrderRequest handles API Gateway requests
 func HandleOrderRequest(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
-	fmt.Printf("🔥 Received event: %+v\n", request)
-	fmt.Printf("🔥 Request body: %s\n", request.Body)
+	fmt.Printf("🔥 Received %s %s, headers: %v\n", request.RequestContext.HTTP.Method, request.RawPath, apigw.LogHeaders(request))
+
+	body, err := apigw.Body(request)
+	if err != nil {
+		fmt.Printf("❌ Error reading body: %v\n", err)
+		return errorResponse(http.StatusBadRequest, "invalid_body", err.Error()), nil
+	}
+
+	authenticator, err := newAuthenticatorFromEnv()
+	if err != nil {
+		fmt.Printf("❌ Error configuring authenticator: %v\n", err)
+		return errorResponse(http.StatusInternalServerError, "auth_not_configured", "request authentication is not configured"), nil
+	}
+
+	if err := authenticator.Authenticate(request, body); err != nil {
+		fmt.Printf("❌ Request rejected: %v\n", err)
+		var authErr *AuthError
+		if errors.As(err, &authErr) {
+			return errorResponse(authErr.StatusCode, authErr.Code, authErr.Message), nil
+		}
+		return errorResponse(http.StatusUnauthorized, "unauthorized", err.Error()), nil
+	}
 
 	var order Order
-	err := json.Unmarshal([]byte(request.Body), &order)
+	err = json.Unmarshal(body, &order)
 	if err != nil {
 		fmt.Printf("❌ Error unmarshalling body: %v\n", err)
-		return events.APIGatewayV2HTTPResponse{
-			StatusCode: 400,
-			Body:       fmt.Sprintf(`{"error": "Invalid JSON: %v"}`, err),
-		}, nil
+		return errorResponse(http.StatusBadRequest, "invalid_json", fmt.Sprintf("Invalid JSON: %v", err)), nil
 	}
 
-	fmt.Printf("🔥 Order: %+v\n", order)
+	// The order carries customer contact details, which are kept out of logs.
+	fmt.Printf("🔥 Order %s with %d items, %d bytes\n", order.OrderID, len(order.Items), len(body))
+
+	if err := order.Validate(); err != nil {
+		fmt.Printf("❌ Invalid order: %v\n", err)
+		return syncErrorResponse("", order.OrderID, err), nil
+	}
 
-	shopifyOrderId := getShopifyOrderId(order.OrderID)
+	// Stop outbound calls shortly before the Lambda deadline so unfinished
+	// work can still be recorded.
+	ctx, cancel := deadline.WithMargin(ctx, deadline.DefaultMargin)
+	defer cancel()
 
-	if shopifyOrderId == "" {
-		createShopifyOrder(order)
+	shopifyOrderId, err := getShopifyOrderId(ctx, order.OrderID)
+	if err != nil {
+		fmt.Printf("❌ Error looking up Shopify order: %v\n", err)
+		return syncErrorResponse(ActionLookup, order.OrderID, err), nil
+	}
+
+	result := OrderResult{OrderID: order.OrderID}
+	var shopifyOrder *shopify.Order
+
+	if shopifyOrderId == 0 {
+		result.Action = ActionCreated
+		shopifyOrder, err = createShopifyOrder(ctx, order)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return syncErrorResponse(ActionCreate, order.OrderID, err), nil
+		}
 	} else {
-		updateShopifyOrder(order)
+		result.Action = ActionUpdated
+		shopifyOrder, err = updateShopifyOrder(ctx, order, shopifyOrderId)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return syncErrorResponse(ActionUpdate, order.OrderID, err), nil
+		}
 	}
 
-	return events.APIGatewayV2HTTPResponse{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Content-Type": "application/json",
-		},
-		Body: `{"message": "test"}`,
-	}, nil
+	rememberOrder(ctx, order.OrderID, shopifyOrder.ID)
+
+	result.ShopifyOrderID = shopifyOrder.ID
+	result.ShopifyOrderName = shopifyOrder.Name
 
+	return jsonResponse(http.StatusOK, result), nil
 }
 
 func main() {
+	// A missing SHOPIFY_API_VERSION or token fails at cold start rather than
+	// on the first order.
+	if _, err := getShopifyClient(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if _, err := getMappingStore(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
 	lambda.Start(HandleOrderRequest)
 }
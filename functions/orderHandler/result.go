@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"mokobara-middleware/shared/deadline"
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/retry"
+	"mokobara-middleware/shared/shopify"
+)
+
+// Actions reported back to Magento: the past tense on success, the attempted
+// step on failure.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionLookup  = "lookup"
+)
+
+// OrderResult is returned to Magento when the order reached Shopify.
+type OrderResult struct {
+	Action           string `json:"action"`
+	OrderID          string `json:"order_id"`
+	ShopifyOrderID   int64  `json:"shopify_order_id"`
+	ShopifyOrderName string `json:"shopify_order_name"`
+}
+
+// ErrorDetail is the "error" object of every error response.
+type ErrorDetail struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+	// UpstreamStatus and UpstreamBody describe the failed Shopify or Magento
+	// call, if the error came from one.
+	UpstreamStatus int    `json:"upstream_status,omitempty"`
+	UpstreamBody   string `json:"upstream_body,omitempty"`
+}
+
+// ErrorResult is returned to Magento when the order could not be synced.
+type ErrorResult struct {
+	Action  string      `json:"action,omitempty"`
+	OrderID string      `json:"order_id,omitempty"`
+	Error   ErrorDetail `json:"error"`
+}
+
+func jsonResponse(statusCode int, v interface{}) events.APIGatewayV2HTTPResponse {
+	body, _ := json.Marshal(v)
+
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(body),
+	}
+}
+
+// errorResponse builds a JSON error response of the form
+// {"error": {"code": "...", "message": "..."}}.
+func errorResponse(statusCode int, code, message string) events.APIGatewayV2HTTPResponse {
+	return jsonResponse(statusCode, ErrorResult{
+		Error: ErrorDetail{Code: code, Message: message},
+	})
+}
+
+// syncErrorResponse reports a failed sync step. Timeouts and transient
+// upstream errors get a 5xx that Magento can retry; orders Shopify rejects get
+// a 422 since retrying them unchanged will not help.
+func syncErrorResponse(action, orderID string, err error) events.APIGatewayV2HTTPResponse {
+	detail := ErrorDetail{Message: err.Error(), Retryable: retry.IsTransient(err)}
+	statusCode := http.StatusBadGateway
+
+	var validationErr *shopify.ValidationError
+	var shopifyErr *shopify.Error
+	var magentoErr *magento.Error
+
+	switch {
+	case errors.As(err, &validationErr):
+		statusCode = http.StatusUnprocessableEntity
+		detail.Code = "invalid_order"
+
+	case deadline.Exceeded(err):
+		statusCode = http.StatusGatewayTimeout
+		detail.Code = "timeout"
+
+	case errors.As(err, &shopifyErr):
+		detail.UpstreamStatus = shopifyErr.StatusCode
+		detail.UpstreamBody = shopifyErr.Body
+		switch {
+		case shopifyErr.Transient():
+			statusCode = http.StatusServiceUnavailable
+			detail.Code = "shopify_unavailable"
+		case shopifyErr.StatusCode == http.StatusUnprocessableEntity:
+			statusCode = http.StatusUnprocessableEntity
+			detail.Code = "shopify_rejected"
+		default:
+			detail.Code = "shopify_error"
+		}
+
+	case errors.As(err, &magentoErr):
+		detail.UpstreamStatus = magentoErr.StatusCode
+		detail.UpstreamBody = magentoErr.Body
+		if magentoErr.Transient() {
+			statusCode = http.StatusServiceUnavailable
+			detail.Code = "magento_unavailable"
+		} else {
+			detail.Code = "magento_error"
+		}
+
+	case detail.Retryable:
+		statusCode = http.StatusServiceUnavailable
+		detail.Code = "upstream_unavailable"
+
+	default:
+		statusCode = http.StatusInternalServerError
+		detail.Code = "internal_error"
+	}
+
+	return jsonResponse(statusCode, ErrorResult{Action: action, OrderID: orderID, Error: detail})
+}
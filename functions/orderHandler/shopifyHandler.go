@@ -1,122 +1,82 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
-	"net/http"
-	"os"
-	"time"
+
+	"mokobara-middleware/shared/mapping"
 )
 
-func makeRequest(url, token string) (*http.Response, error) {
-	req, err := http.NewRequest("GET", url, nil)
+// getShopifyOrderId returns the ID of the Shopify order recorded for the
+// Magento order ID in the mapping store or, failing that, tagged with it in
+// Shopify. It returns 0 if there is none.
+func getShopifyOrderId(ctx context.Context, orderID string) (int64, error) {
+	store, err := getMappingStore()
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+		return 0, fmt.Errorf("failed to configure mapping store: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-Shopify-Access-Token", token)
-
-	return http.DefaultClient.Do(req)
-}
-
-// func fetchResponseBody(resp *http.Response) ([]byte, error) {
-// 	defer resp.Body.Close()
-
-// 	if resp.StatusCode != 200 {
-// 		return nil, fmt.Errorf("received non-200 status code: %d", resp.StatusCode)
-// 	}
-
-// 	return io.ReadAll(resp.Body)
-// }
-
-func getShopifyOrderId(orderID string) string {
-	// If the order is found in Shopify, it will return true
-	// If not found, it will return false
-
-	storeName := os.Getenv("STORE_NAME")
-	shopifyToken := os.Getenv("SHOPIFY_TOKEN")
-
-	// Fetch order
-	orderURL := fmt.Sprintf("https://%s.myshopify.com/admin/api/2023-04/orders.json?tag=%s", storeName, orderID)
-
-	// check if the order exists in Shopify
-	res, err := makeRequest(orderURL, shopifyToken)
-
-	if err != nil {
-		fmt.Printf("❌ Error making request: %v\n", err)
-		return ""
+	known, err := store.Get(ctx, mapping.KindOrder, orderID)
+	if err == nil && known.ShopifyID != 0 {
+		fmt.Printf("✅ Order with orderID '%s' mapped to Shopify order %d\n", orderID, known.ShopifyID)
+		return known.ShopifyID, nil
 	}
-
-	// Check the response status code
-	if res.StatusCode != http.StatusOK {
-		fmt.Printf("❌ Error: Status code %d\n", res.StatusCode)
-		return ""
+	if err != nil && !mapping.IsNotFound(err) {
+		return 0, fmt.Errorf("failed to read order mapping: %w", err)
 	}
 
-	// Parse the response
-	var responseData struct {
-		Orders []struct {
-			ID   int64  `json:"id"`
-			Name string `json:"name"`
-			Tags string `json:"tags"`
-		} `json:"orders"`
+	client, err := getShopifyClient()
+	if err != nil {
+		return 0, fmt.Errorf("failed to configure Shopify client: %w", err)
 	}
-	err = json.NewDecoder(res.Body).Decode(&responseData)
+
+	// check if the order exists in Shopify
+	orders, err := client.FindOrdersByTag(ctx, orderID)
 	if err != nil {
-		fmt.Printf("❌ Error decoding response: %v\n", err)
-		return ""
+		return 0, fmt.Errorf("failed to search Shopify orders: %w", err)
 	}
 
 	// Check if any orders were returned
-	if len(responseData.Orders) > 0 {
-		fmt.Printf("✅ Order with orderID '%s' found: %s\n", orderID, responseData.Orders[0].Name)
-		return fmt.Sprintf("%d", responseData.Orders[0].ID)
+	if len(orders) > 0 {
+		fmt.Printf("✅ Order with orderID '%s' found: %s\n", orderID, orders[0].Name)
+		return orders[0].ID, nil
 	}
 
-	fmt.Printf("❌ No order found with orderID '%s'\n", orderID)
-	return ""
+	fmt.Printf("🔥 No order found with orderID '%s'\n", orderID)
+	return 0, nil
 }
 
-func getOrderStatus(orderID string) string {
-	apiURL := os.Getenv("BASE_URL")
-	if apiURL == "" {
-		log.Println("updateProduct: ❌ BASE_URL environment variable not set")
-		return ""
+// rememberOrder records which Shopify order a Magento order became. A failed
+// write only costs a Shopify search on the next push, so it is only logged.
+func rememberOrder(ctx context.Context, orderID string, shopifyOrderID int64) {
+	store, err := getMappingStore()
+	if err == nil {
+		err = store.Put(ctx, mapping.Record{
+			Kind:      mapping.KindOrder,
+			Key:       orderID,
+			ShopifyID: shopifyOrderID,
+			MagentoID: orderID,
+		})
 	}
-
-	req, err := http.NewRequest("GET", apiURL+"/rest/V1/orders/"+orderID+"/status", nil)
 	if err != nil {
-		log.Printf("❌ Failed to create request: %v\n", err)
-		return ""
+		fmt.Printf("⚠️ Error storing mapping for order %s: %v\n", orderID, err)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	token := os.Getenv("URL_TOKEN")
-	if token == "" {
-		log.Println("❌ URL_TOKEN environment variable not set")
-		return ""
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
+}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+func getOrderStatus(ctx context.Context, orderID string) (string, error) {
+	client, err := getMagentoClient()
 	if err != nil {
-		log.Printf("❌ Failed to send request: %v\n", err)
-		return ""
+		return "", fmt.Errorf("failed to configure Magento client: %w", err)
 	}
-	defer resp.Body.Close()
-	log.Printf("🌐 Response Status: %d\n", resp.StatusCode)
 
-	var statusResponse struct {
-		Status      string `json:"status"`
-		StatusLabel string `json:"status_label"`
-	}
-
-	err = json.NewDecoder(resp.Body).Decode(&statusResponse)
+	status, err := client.GetOrderStatus(ctx, orderID)
 	if err != nil {
-		log.Printf("❌ Error decoding response: %v\n", err)
-		return ""
+		log.Printf("❌ Error fetching order status: %v\n", err)
+		return "", err
+	}
+	if status == "" {
+		return "", fmt.Errorf("status not found for order: %s", orderID)
 	}
 
-	return statusResponse.Status
+	return status, nil
 }
@@ -1,246 +1,252 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"os"
-	"time"
-)
+	"slices"
+	"strconv"
 
-func getProductPayload(body map[string]interface{}) ([]map[string]interface{}, error) {
-	productData, ok := body["product"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("❌ invalid product data structure")
-	}
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/shopify"
+)
 
-	variants, ok := productData["variants"].([]interface{})
-	if !ok {
+func getProductPayload(productData shopify.Product, mapping *FieldMapping) ([]magento.ProductRequest, error) {
+	if len(productData.Variants) == 0 {
 		return nil, fmt.Errorf("❌ no variants found in product")
 	}
-	slug, _ := productData["handle"].(string)
+	strategy, err := getSKUStrategy()
+	if err != nil {
+		return nil, err
+	}
+	skus, err := variantSKUs(strategy, productData)
+	if err != nil {
+		return nil, err
+	}
 
-	products := []map[string]interface{}{}
-	for _, item := range variants {
-		variant, ok := item.(map[string]interface{})
-		if !ok {
-			fmt.Println("❌ Invalid variant structure")
-			continue
-		}
+	products := []magento.ProductRequest{}
+	for i, variant := range productData.Variants {
+		title := fmt.Sprintf("%s %s", productData.Title, variant.Title)
+		inventoryQuantity := float64(variant.InventoryQuantity)
 
-		sku, _ := variant["sku"].(string)
-		title := fmt.Sprintf("%s %s", productData["title"], variant["title"])
-		price, _ := variant["price"].(string)
-		inventoryQuantity := float64(0)
-		if qty, ok := variant["inventory_quantity"].(float64); ok {
-			inventoryQuantity = qty
+		price, specialPrice, err := variantPrices(variant)
+		if err != nil {
+			return nil, err
 		}
 
-		combinedSKU := fmt.Sprintf("%s-%s", slug, sku)
-
-		products = append(products, map[string]interface{}{
-			"product": map[string]interface{}{
-				"sku":              combinedSKU,
-				"name":             title,
-				"price":            price,
-				"status":           1,
-				"visibility":       4,
-				"type_id":          "simple",
-				"weight":           1.0,
-				"attribute_set_id": 92,
-				"extension_attributes": map[string]interface{}{
-					"stock_item": map[string]interface{}{
-						"qty":         inventoryQuantity,
-						"is_in_stock": inventoryQuantity > 0,
-					},
-				},
-				"custom_attributes": []map[string]interface{}{
-					{
-						"attribute_code": "description",
-						"value":          productData["body_html"],
-					},
+		product := magento.Product{
+			SKU:    skus[i],
+			Name:   title,
+			Price:  price,
+			TypeID: magento.TypeSimple,
+			ExtensionAttributes: &magento.ExtensionAttributes{
+				StockItem: &magento.StockItem{
+					Qty:       inventoryQuantity,
+					IsInStock: inventoryQuantity > 0,
 				},
 			},
-		})
+		}
+		applyFieldMapping(mapping, &product, productData, &variant)
+		applyVariantWeight(&product.Weight, productData, variant)
+		// special_price is only sent during a sale; manageProduct clears
+		// one that ended.
+		if specialPrice != "" {
+			product.SetCustomAttribute("special_price", specialPrice)
+		}
+		product.SetCustomAttribute(getShopifyIDAttribute(), strconv.FormatInt(productData.ID, 10))
+
+		products = append(products, magento.ProductRequest{Product: product})
 	}
 
 	return products, nil
 }
 
-func manageProduct(product map[string]interface{}) error {
-	productJSON, err := json.Marshal(product)
+// variantPrices returns the Magento price and special_price of a variant. A
+// Shopify sale is a compare_at_price above the price: Magento then gets the
+// original price as price and the sale price as special_price. Without a sale
+// specialPrice is empty.
+func variantPrices(variant shopify.Variant) (price float64, specialPrice string, err error) {
+	price, err = strconv.ParseFloat(variant.Price, 64)
 	if err != nil {
-		log.Printf("❌ Failed to marshal product: %v\n", err)
-		return fmt.Errorf("failed to marshal product: %v", err)
+		return 0, "", fmt.Errorf("❌ invalid price %q for variant %d: %w", variant.Price, variant.ID, err)
 	}
 
-	apiURL := os.Getenv("BASE_URL")
-	if apiURL == "" {
-		log.Println("❌ BASE_URL environment variable not set")
-		return fmt.Errorf("BASE_URL environment variable not set")
+	if variant.CompareAtPrice == "" {
+		return price, "", nil
 	}
-	log.Printf("🔥 Product JSON: %s\n", productJSON)
-
-	productMap := product["product"].(map[string]interface{})
-	sku := productMap["sku"].(string)
-
-	req, err := http.NewRequest("GET", apiURL+"/rest/V1/products/"+sku, nil)
+	compareAt, err := strconv.ParseFloat(variant.CompareAtPrice, 64)
 	if err != nil {
-		log.Printf("❌ Failed to create request: %v\n", err)
-		return fmt.Errorf("failed to create request: %v", err)
+		return 0, "", fmt.Errorf("❌ invalid compare_at_price %q for variant %d: %w", variant.CompareAtPrice, variant.ID, err)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	token := os.Getenv("URL_TOKEN")
-	if token == "" {
-		log.Println("❌ URL_TOKEN environment variable not set")
-		return fmt.Errorf("URL_TOKEN environment variable not set")
+	if compareAt <= price {
+		return price, "", nil
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
+	return compareAt, variant.Price, nil
+}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("❌ Failed to send request: %v\n", err)
-		return fmt.Errorf("failed to send request: %v", err)
+// specialPrice returns the special_price a Magento product has, "" for none.
+func specialPrice(product magento.Product) string {
+	value, ok := product.CustomAttribute("special_price")
+	if !ok || value == nil {
+		return ""
 	}
-	defer resp.Body.Close()
-	log.Printf("🌐 Response Status: %d\n", resp.StatusCode)
-	// log the response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("❌ Failed to read response: %v\n", err)
-		return fmt.Errorf("failed to read response: %v", err)
-	}
-
-	log.Printf("🌐 Body Length: %d\n", len(body))
-
-	// log the response body
-	log.Printf("🌐 Response Body: %s\n", string(body))
+	return fmt.Sprint(value)
+}
 
-	// if product exists, update it else create it
-	if resp.StatusCode == 200 {
-		return updateProduct(product)
-	} else if resp.StatusCode == 404 {
-		return createProduct(product)
-	} else {
-		log.Printf("❌ API call failed with status code %d: %s\n", resp.StatusCode, string(body))
-		return fmt.Errorf("API call failed with status code %d: %s", resp.StatusCode, string(body))
+// clearEndedSale returns product with special_price set to null when it has
+// no sale but previous, the special price Magento has, is set. Magento keeps
+// an omitted special_price, so an ended sale has to be cleared explicitly.
+func clearEndedSale(product magento.ProductRequest, previous string) magento.ProductRequest {
+	if previous == "" || specialPrice(product.Product) != "" {
+		return product
 	}
+	product.Product.CustomAttributes = slices.Clone(product.Product.CustomAttributes)
+	product.Product.SetCustomAttribute("special_price", nil)
+	return product
 }
 
-func updateProduct(product map[string]interface{}) error {
+// manageProduct creates or updates one Magento product. Products found in the
+// mapping store are updated directly, or skipped if the payload hash matches
+// the last synced one; others are looked up by SKU first.
+// record describes the Shopify object the product is synced from.
+func manageProduct(ctx context.Context, client *magento.Client, product magento.ProductRequest, record mapping.Record) (SyncStatus, error) {
+	sku := product.Product.SKU
 
 	productJSON, err := json.Marshal(product)
 	if err != nil {
-		log.Printf(":updateProduct ❌ Failed to marshal product: %v\n", err)
-		return fmt.Errorf("failed to marshal product: %v", err)
-	}
-
-	apiURL := os.Getenv("BASE_URL")
-	if apiURL == "" {
-		log.Println("updateProduct: ❌ BASE_URL environment variable not set")
-		return fmt.Errorf("BASE_URL environment variable not set")
+		log.Printf("❌ Failed to marshal product: %v\n", err)
+		return SyncFailed, fmt.Errorf("failed to marshal product: %v", err)
 	}
 	log.Printf("🔥 Product JSON: %s\n", productJSON)
 
-	productMap := product["product"].(map[string]interface{})
-	sku := productMap["sku"].(string)
-
-	req, err := http.NewRequest("PUT", apiURL+"/rest/V1/products/"+sku, bytes.NewBuffer(productJSON))
+	store, err := getMappingStore()
 	if err != nil {
-		log.Printf("updateProduct: ❌ Failed to create request: %v\n", err)
-		return fmt.Errorf("failed to create request: %v", err)
+		return SyncFailed, err
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	token := os.Getenv("URL_TOKEN")
-	if token == "" {
-		log.Println("updateProduct: ❌ URL_TOKEN environment variable not set")
-		return fmt.Errorf("URL_TOKEN environment variable not set")
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	known, err := knownProduct(ctx, store, record)
 	if err != nil {
-		log.Printf("updateProduct: ❌ Failed to send request: %v\n", err)
-		return fmt.Errorf("failed to send request: %v", err)
+		return SyncFailed, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	record.Hash, err = payloadHash(product)
 	if err != nil {
-		log.Printf("updateProduct: ❌ Failed to read response: %v\n", err)
-		return fmt.Errorf("failed to read response: %v", err)
+		return SyncFailed, err
+	}
+	record.SpecialPrice = specialPrice(product.Product)
+	if known != nil && known.MagentoSKU == sku && known.Hash == record.Hash {
+		log.Printf("✅ Product unchanged since last sync: %s\n", sku)
+		return SyncUnchanged, nil
+	}
+
+	// The SKU changed since the last sync: rename the product in place so it
+	// keeps its history, unless the new SKU is already taken.
+	if known != nil && known.MagentoSKU != sku && known.MagentoID != "" {
+		if id, err := strconv.Atoi(known.MagentoID); err == nil {
+			if _, err := client.GetProduct(ctx, sku); magento.IsNotFound(err) {
+				if _, err := client.RenameProduct(ctx, id, known.MagentoSKU, sku); err != nil {
+					return SyncFailed, err
+				}
+				log.Printf("🔁 Renamed SKU %s to %s\n", known.MagentoSKU, sku)
+				known.MagentoSKU = sku
+			}
+		}
 	}
 
-	log.Printf("🌐 Response Status: %d\n", resp.StatusCode)
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		log.Printf("updateProduct: ❌ API call failed with status code %d: %s\n", resp.StatusCode, string(body))
-		return fmt.Errorf("API call failed with status code %d: %s", resp.StatusCode, string(body))
+	if known != nil && known.MagentoSKU == sku {
+		saved, err := updateProduct(ctx, client, clearEndedSale(product, known.SpecialPrice))
+		if err == nil {
+			rememberProduct(ctx, store, record, saved)
+			return SyncUpdated, nil
+		}
+		if !magento.IsNotFound(err) {
+			return SyncFailed, err
+		}
+		// Deleted in Magento since the last sync; create it again.
+	} else {
+		// if product exists, update it else create it
+		existing, err := client.GetProduct(ctx, sku)
+		if err == nil {
+			if err := checkSKUOwner(existing, product.Product); err != nil {
+				log.Printf("❌ SKU collision: %v\n", err)
+				return SyncFailed, err
+			}
+			saved, err := updateProduct(ctx, client, clearEndedSale(product, specialPrice(*existing)))
+			if err != nil {
+				return SyncFailed, err
+			}
+			rememberProduct(ctx, store, record, saved)
+			return SyncUpdated, nil
+		}
+		if !magento.IsNotFound(err) {
+			log.Printf("❌ API call failed: %v\n", err)
+			return SyncFailed, err
+		}
 	}
 
-	log.Printf("✅ Product updated successfully: %s\n", sku)
-
-	return nil
+	saved, err := createProduct(ctx, client, product)
+	if err != nil {
+		return SyncFailed, err
+	}
+	rememberProduct(ctx, store, record, saved)
+	return SyncCreated, nil
 }
 
-func createProduct(product map[string]interface{}) error {
-
-	productJSON, err := json.Marshal(product)
+func updateProduct(ctx context.Context, client *magento.Client, product magento.ProductRequest) (*magento.Product, error) {
+	saved, err := client.UpdateProduct(ctx, product)
 	if err != nil {
-		log.Printf("createProduct: ❌ Failed to marshal product: %v\n", err)
-		return fmt.Errorf("failed to marshal product: %v", err)
+		log.Printf("updateProduct: ❌ %v\n", err)
+		return nil, err
 	}
 
-	apiURL := os.Getenv("BASE_URL")
-	if apiURL == "" {
-		log.Println("createProduct: ❌ BASE_URL environment variable not set")
-		return fmt.Errorf("BASE_URL environment variable not set")
-	}
-	log.Printf("🔥 Product JSON: %s\n", productJSON)
+	log.Printf("✅ Product updated successfully: %s\n", product.Product.SKU)
+	return saved, nil
+}
 
-	req, err := http.NewRequest("POST", apiURL+"/rest/V1/products", bytes.NewBuffer(productJSON))
+func createProduct(ctx context.Context, client *magento.Client, product magento.ProductRequest) (*magento.Product, error) {
+	saved, err := client.CreateProduct(ctx, product)
 	if err != nil {
-		log.Printf("createProduct: ❌ Failed to create request: %v\n", err)
-		return fmt.Errorf("failed to create request: %v", err)
+		log.Printf("createProduct: ❌ %v\n", err)
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	token := os.Getenv("URL_TOKEN")
-	if token == "" {
-		log.Println("createProduct: ❌ URL_TOKEN environment variable not set")
-		return fmt.Errorf("URL_TOKEN environment variable not set")
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
+	log.Printf("✅ Product created successfully: %s\n", product.Product.SKU)
+	return saved, nil
+}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Printf("createProduct: ❌ Failed to send request: %v\n", err)
-		return fmt.Errorf("failed to send request: %v", err)
+// unpublishProduct takes an existing Magento product off the storefront.
+// Products that were never synced are skipped.
+func unpublishProduct(ctx context.Context, client *magento.Client, sku string) (SyncStatus, error) {
+	existing, err := client.GetProduct(ctx, sku)
+	if magento.IsNotFound(err) {
+		log.Printf("🔥 Product %s not in Magento, nothing to unpublish\n", sku)
+		return SyncSkipped, nil
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("createProduct: ❌ Failed to read response: %v\n", err)
-		return fmt.Errorf("failed to read response: %v", err)
+		log.Printf("unpublishProduct: ❌ %v\n", err)
+		return SyncFailed, err
 	}
 
-	log.Printf("🌐 Response Status: %d\n", resp.StatusCode)
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		log.Printf("createProduct: ❌ API call failed with status code %d: %s\n", resp.StatusCode, string(body))
-		return fmt.Errorf("API call failed with status code %d: %s", resp.StatusCode, string(body))
+	if getUnpublishMode() == UnpublishModeHide {
+		if existing.Visibility == magento.VisibilityNotVisible {
+			return SyncSkipped, nil
+		}
+		if err := client.SetProductVisibility(ctx, sku, magento.VisibilityNotVisible); err != nil {
+			log.Printf("unpublishProduct: ❌ %v\n", err)
+			return SyncFailed, err
+		}
+		log.Printf("✅ Product hidden successfully: %s\n", sku)
+		return SyncHidden, nil
 	}
 
-	sku := product["product"].(map[string]interface{})["sku"].(string)
-
-	log.Printf("✅ Product created successfully: %s\n", sku)
-	return nil
+	if existing.Status == magento.StatusDisabled {
+		return SyncSkipped, nil
+	}
+	if err := client.SetProductStatus(ctx, sku, magento.StatusDisabled); err != nil {
+		log.Printf("unpublishProduct: ❌ %v\n", err)
+		return SyncFailed, err
+	}
+	log.Printf("✅ Product disabled successfully: %s\n", sku)
+	return SyncDisabled, nil
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/shopify"
+)
+
+func TestGetProductPayloadSpecialPrice(t *testing.T) {
+	product := shopify.Product{
+		ID:     7,
+		Handle: "bag",
+		Variants: []shopify.Variant{
+			{ID: 11, SKU: "BAG-01", Price: "900.00", CompareAtPrice: "1200.00"},
+			{ID: 12, SKU: "BAG-02", Price: "900.00", CompareAtPrice: "900.00"},
+			{ID: 13, SKU: "BAG-03", Price: "900.00"},
+		},
+	}
+	payload, err := getProductPayload(product, &FieldMapping{Defaults: MappingDefaults{AttributeSetID: 4}})
+	if err != nil {
+		t.Fatalf("getProductPayload() error = %v", err)
+	}
+
+	want := []string{"900.00", "", ""}
+	for i, request := range payload {
+		value, ok := request.Product.CustomAttribute("special_price")
+		if got := specialPrice(request.Product); got != want[i] || (want[i] == "" && ok) {
+			t.Errorf("%s special_price = %v (set %v), want %q", request.Product.SKU, value, ok, want[i])
+		}
+	}
+}
+
+// sentSpecialPrice decodes a product request body and returns its
+// special_price and whether it was sent at all.
+func sentSpecialPrice(t *testing.T, body []byte) (interface{}, bool) {
+	t.Helper()
+	var request magento.ProductRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		t.Fatalf("invalid product request %s: %v", body, err)
+	}
+	return request.Product.CustomAttribute("special_price")
+}
+
+func TestManageProductSpecialPrice(t *testing.T) {
+	ctx := context.Background()
+	store, err := getMappingStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		key      int64
+		previous string // special price stored in the mapping record
+		existing string // Magento product, for a SKU the mapping store does not know
+		sale     string
+		want     interface{}
+		wantSent bool
+	}{
+		{name: "sale starts", key: 9401, sale: "900.00", want: "900.00", wantSent: true},
+		{name: "sale ends", key: 9402, previous: "900.00", want: nil, wantSent: true},
+		{name: "no sale before or now", key: 9403, wantSent: false},
+		{name: "sale continues", key: 9404, previous: "900.00", sale: "800.00", want: "800.00", wantSent: true},
+		{name: "unmapped product with a sale in Magento", key: 9405, existing: `{"sku": "bag-9405", "custom_attributes": [{"attribute_code": "special_price", "value": "900.000000"}]}`, want: nil, wantSent: true},
+		{name: "unmapped product without a sale", key: 9406, existing: `{"sku": "bag-9406"}`, wantSent: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sku := "bag-" + strconv.FormatInt(tt.key, 10)
+			record := mapping.Record{Kind: mapping.KindVariant, Key: strconv.FormatInt(tt.key, 10), ShopifyID: tt.key, MagentoSKU: sku}
+			if tt.existing == "" {
+				stored := record
+				stored.SpecialPrice = tt.previous
+				stored.Hash = "old"
+				if err := store.Put(ctx, stored); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Cleanup(func() { store.Delete(ctx, record.Kind, record.Key) })
+
+			var body []byte
+			client := newTestMagento(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					w.Write([]byte(tt.existing))
+					return
+				}
+				body, _ = io.ReadAll(r.Body)
+				w.Write([]byte(`{"id": 1, "sku": "` + sku + `"}`))
+			})
+
+			product := magento.ProductRequest{Product: magento.Product{SKU: sku, Price: 1200}}
+			if tt.sale != "" {
+				product.Product.SetCustomAttribute("special_price", tt.sale)
+			}
+			status, err := manageProduct(ctx, client, product, record)
+			if err != nil || status != SyncUpdated {
+				t.Fatalf("manageProduct() = %s, %v, want %s", status, err, SyncUpdated)
+			}
+
+			got, sent := sentSpecialPrice(t, body)
+			if sent != tt.wantSent || got != tt.want {
+				t.Errorf("sent special_price = %v (sent %v), want %v (sent %v)", got, sent, tt.want, tt.wantSent)
+			}
+			if _, ok := product.Product.CustomAttribute("special_price"); ok != (tt.sale != "") {
+				t.Error("manageProduct() changed the caller's payload")
+			}
+
+			known, err := store.Get(ctx, record.Kind, record.Key)
+			if err != nil || known.SpecialPrice != tt.sale {
+				t.Errorf("stored special price = %+v, %v, want %q", known, err, tt.sale)
+			}
+		})
+	}
+}
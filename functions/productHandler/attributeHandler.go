@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"mokobara-middleware/shared/magento"
+)
+
+// provisionTimeout bounds provisioning at cold start.
+const provisionTimeout = 30 * time.Second
+
+// provisionShopifyIDAttribute creates the Shopify ID attribute in the default
+// attribute set of the field mapping. A failure is only logged: syncs still
+// work, and deletes fall back to the mapping store.
+func provisionShopifyIDAttribute() {
+	ctx, cancel := context.WithTimeout(context.Background(), provisionTimeout)
+	defer cancel()
+
+	client, err := getMagentoClient()
+	if err != nil {
+		fmt.Printf("⚠️ Cannot provision Magento attributes: %v\n", err)
+		return
+	}
+	mapping, err := getFieldMapping()
+	if err != nil {
+		fmt.Printf("⚠️ Cannot provision Magento attributes: %v\n", err)
+		return
+	}
+
+	if err := ensureShopifyIDAttribute(ctx, client, mapping.Defaults.AttributeSetID); err != nil {
+		fmt.Printf("⚠️ Error provisioning Magento attribute %s: %v\n", getShopifyIDAttribute(), err)
+		return
+	}
+	fmt.Printf("✅ Magento attribute %s is provisioned\n", getShopifyIDAttribute())
+}
+
+// ensureShopifyIDAttribute makes sure the attribute named by
+// MAGENTO_SHOPIFY_ID_ATTRIBUTE exists and belongs to attributeSetID.
+// Magento drops custom attributes that are not part of a product's attribute
+// set, and searching on an unknown attribute fails, so without it deleted
+// Shopify products could only be found through the mapping store.
+func ensureShopifyIDAttribute(ctx context.Context, client *magento.Client, attributeSetID int) error {
+	code := getShopifyIDAttribute()
+	_, err := client.GetAttribute(ctx, code)
+	if magento.IsNotFound(err) {
+		fmt.Printf("🔥 Creating Magento attribute %s\n", code)
+		_, err = client.CreateAttribute(ctx, magento.Attribute{
+			AttributeCode:        code,
+			FrontendInput:        "text",
+			DefaultFrontendLabel: "Shopify Product ID",
+			Scope:                "global",
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to provision attribute %s: %w", code, err)
+	}
+
+	groups, err := client.GetAttributeGroups(ctx, attributeSetID)
+	if err != nil {
+		return fmt.Errorf("failed to read groups of attribute set %d: %w", attributeSetID, err)
+	}
+	if len(groups) == 0 {
+		return fmt.Errorf("attribute set %d has no groups", attributeSetID)
+	}
+	group := groups[0]
+	for _, g := range groups {
+		if strings.EqualFold(g.AttributeGroupName, "General") {
+			group = g
+		}
+	}
+	if err := client.AssignAttribute(ctx, attributeSetID, group.AttributeGroupID, code); err != nil {
+		return fmt.Errorf("failed to add attribute %s to attribute set %d: %w", code, attributeSetID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEnsureShopifyIDAttribute(t *testing.T) {
+	tests := []struct {
+		name     string
+		exists   bool
+		wantCall []string
+	}{
+		{
+			name:   "missing attribute is created and assigned",
+			exists: false,
+			wantCall: []string{
+				"GET /rest/V1/products/attributes/shopify_product_id",
+				"POST /rest/V1/products/attributes",
+				"GET /rest/V1/products/attribute-sets/groups/list",
+				"POST /rest/V1/products/attribute-sets/attributes",
+			},
+		},
+		{
+			name:   "existing attribute is assigned",
+			exists: true,
+			wantCall: []string{
+				"GET /rest/V1/products/attributes/shopify_product_id",
+				"GET /rest/V1/products/attribute-sets/groups/list",
+				"POST /rest/V1/products/attribute-sets/attributes",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			var assigned string
+			client := newTestMagento(t, func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, r.Method+" "+r.URL.Path)
+				switch {
+				case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/rest/V1/products/attributes/"):
+					if !tt.exists {
+						w.WriteHeader(http.StatusNotFound)
+						w.Write([]byte(`{"message": "not found"}`))
+						return
+					}
+					w.Write([]byte(`{"attribute_id": 200, "attribute_code": "shopify_product_id"}`))
+				case strings.HasSuffix(r.URL.Path, "/groups/list"):
+					w.Write([]byte(`{"items": [{"attribute_group_id": "7", "attribute_group_name": "Content"}, {"attribute_group_id": "8", "attribute_group_name": "General"}]}`))
+				case strings.HasSuffix(r.URL.Path, "/attribute-sets/attributes"):
+					body, _ := io.ReadAll(r.Body)
+					assigned = string(body)
+					w.Write([]byte(`"200"`))
+				default:
+					w.Write([]byte(`{"attribute_id": 200, "attribute_code": "shopify_product_id"}`))
+				}
+			})
+
+			ctx := context.Background()
+			if err := ensureShopifyIDAttribute(ctx, client, 4); err != nil {
+				t.Fatalf("ensureShopifyIDAttribute() error = %v", err)
+			}
+			if strings.Join(calls, "\n") != strings.Join(tt.wantCall, "\n") {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCall)
+			}
+			if !strings.Contains(assigned, `"attributeGroupId":"8"`) || !strings.Contains(assigned, `"attributeSetId":4`) {
+				t.Errorf("assign body = %s, want the General group of set 4", assigned)
+			}
+		})
+	}
+}
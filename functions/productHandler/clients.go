@@ -0,0 +1,29 @@
+package main
+
+import (
+	"sync"
+
+	"mokobara-middleware/shared/dedup"
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/queue"
+	"mokobara-middleware/shared/shopify"
+)
+
+// getMagentoClient and getShopifyClient return the clients shared by every
+// invocation of this Lambda instance, creating them on first use.
+var (
+	getMagentoClient = sync.OnceValues(magento.NewFromEnv)
+	getShopifyClient = sync.OnceValues(shopify.NewFromEnv)
+)
+
+// getMappingStore returns the Shopify to Magento mapping store selected by
+// MAPPING_STORE.
+var getMappingStore = sync.OnceValues(mapping.NewFromEnv)
+
+// getDedupStore returns the store of processed webhooks selected by
+// DEDUP_STORE.
+var getDedupStore = sync.OnceValues(dedup.NewFromEnv)
+
+// getSyncQueue returns the queue webhooks are handed to in async mode.
+var getSyncQueue = sync.OnceValues(queue.NewFromEnv)
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Product delete modes selected by PRODUCT_DELETE_MODE.
+const (
+	DeleteModeDisable = "disable"
+	DeleteModeDelete  = "delete"
+)
+
+// Unpublish modes selected by PRODUCT_UNPUBLISH_MODE.
+const (
+	UnpublishModeDisable = "disable"
+	UnpublishModeHide    = "hide"
+)
+
+// Stock sync modes selected by STOCK_SYNC_MODE.
+const (
+	StockModeLegacy = "legacy"
+	StockModeMSI    = "msi"
+)
+
+// Product modes selected by PRODUCT_MODE.
+const (
+	ProductModeSimple       = "simple"
+	ProductModeConfigurable = "configurable"
+)
+
+const defaultShopifyIDAttribute = "shopify_product_id"
+
+// Sync modes selected by SYNC_MODE.
+const (
+	SyncModeSync  = "sync"
+	SyncModeAsync = "async"
+)
+
+// Function roles selected by FUNCTION_ROLE.
+const (
+	FunctionRoleWebhook = "webhook"
+	FunctionRoleWorker  = "worker"
+)
+
+// getDeleteMode returns how products deleted in Shopify are handled in
+// Magento. Products are disabled unless PRODUCT_DELETE_MODE is "delete".
+func getDeleteMode() string {
+	if os.Getenv("PRODUCT_DELETE_MODE") == DeleteModeDelete {
+		return DeleteModeDelete
+	}
+	return DeleteModeDisable
+}
+
+// getShopifyIDAttribute returns the Magento attribute that stores the Shopify
+// product ID on every synced SKU. The attribute must exist and belong to the
+// attribute set of synced products, or Magento drops it and deleted products
+// can only be found through the mapping store. MAGENTO_PROVISION_ATTRIBUTES
+// creates it.
+func getShopifyIDAttribute() string {
+	if attribute := os.Getenv("MAGENTO_SHOPIFY_ID_ATTRIBUTE"); attribute != "" {
+		return attribute
+	}
+	return defaultShopifyIDAttribute
+}
+
+// getUnpublishMode returns how products whose is_published metafield is false
+// are taken off the Magento storefront: disabled (status=2) unless
+// PRODUCT_UNPUBLISH_MODE is "hide" (visibility=1).
+func getUnpublishMode() string {
+	if os.Getenv("PRODUCT_UNPUBLISH_MODE") == UnpublishModeHide {
+		return UnpublishModeHide
+	}
+	return UnpublishModeDisable
+}
+
+// getStockMode returns how stock is written to Magento: through the legacy
+// stockItems API unless STOCK_SYNC_MODE is "msi".
+func getStockMode() string {
+	if os.Getenv("STOCK_SYNC_MODE") == StockModeMSI {
+		return StockModeMSI
+	}
+	return StockModeLegacy
+}
+
+// getSourceCodes maps Shopify location IDs to MSI source codes, read from
+// MAGENTO_SOURCE_MAP as "locationID=source_code,...".
+func getSourceCodes() map[int64]string {
+	sources := map[int64]string{}
+	for _, pair := range strings.Split(os.Getenv("MAGENTO_SOURCE_MAP"), ",") {
+		location, source, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		locationID, err := strconv.ParseInt(strings.TrimSpace(location), 10, 64)
+		if err != nil {
+			continue
+		}
+		sources[locationID] = strings.TrimSpace(source)
+	}
+	return sources
+}
+
+// getSourceCode returns the MSI source for a Shopify location. ok is false
+// for unmapped locations, whose stock must not land in another source.
+func getSourceCode(sources map[int64]string, locationID int64) (source string, ok bool) {
+	source, ok = sources[locationID]
+	return source, ok && source != ""
+}
+
+// getProductMode returns how Shopify variants are created in Magento: as
+// unrelated simple products unless PRODUCT_MODE is "configurable".
+func getProductMode() string {
+	if os.Getenv("PRODUCT_MODE") == ProductModeConfigurable {
+		return ProductModeConfigurable
+	}
+	return ProductModeSimple
+}
+
+// getOptionAttribute returns the Magento super attribute for a Shopify option
+// name. MAGENTO_OPTION_ATTRIBUTES maps names explicitly as "Size=size,...";
+// other options use their lowercased name.
+func getOptionAttribute(optionName string) string {
+	for _, pair := range strings.Split(os.Getenv("MAGENTO_OPTION_ATTRIBUTES"), ",") {
+		name, code, ok := strings.Cut(pair, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(name), optionName) {
+			return strings.TrimSpace(code)
+		}
+	}
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(optionName), " ", "_"))
+}
+
+// getSyncImages reports whether product images are copied to the Magento
+// media gallery. Set SYNC_IMAGES to "false" to turn it off.
+func getSyncImages() bool {
+	return os.Getenv("SYNC_IMAGES") != "false"
+}
+
+// getProvisionAttributes reports whether the Shopify ID attribute is created
+// in Magento at cold start. Set MAGENTO_PROVISION_ATTRIBUTES to "true" to
+// turn it on.
+func getProvisionAttributes() bool {
+	return os.Getenv("MAGENTO_PROVISION_ATTRIBUTES") == "true"
+}
+
+// getWeightUnit returns the Magento store's weight unit, which Shopify
+// weights are converted to: kilograms unless MAGENTO_WEIGHT_UNIT is "lbs".
+func getWeightUnit() string {
+	if os.Getenv("MAGENTO_WEIGHT_UNIT") == WeightUnitLbs {
+		return WeightUnitLbs
+	}
+	return WeightUnitKgs
+}
+
+// getSKUStrategy returns the strategy named by SKU_STRATEGY, handle_sku by
+// default.
+func getSKUStrategy() (SKUStrategy, error) {
+	name := os.Getenv("SKU_STRATEGY")
+	if name == "" {
+		name = SKUStrategyHandleSKU
+	}
+	return newSKUStrategy(name)
+}
+
+// getPreviousSKUStrategy returns the strategy named by SKU_PREVIOUS_STRATEGY,
+// whose SKUs are migrated to the current strategy. ok is false when unset.
+func getPreviousSKUStrategy() (strategy SKUStrategy, ok bool, err error) {
+	name := os.Getenv("SKU_PREVIOUS_STRATEGY")
+	if name == "" {
+		return nil, false, nil
+	}
+	strategy, err = newSKUStrategy(name)
+	return strategy, err == nil, err
+}
+
+// getSyncMode returns whether webhooks are synced before they are answered or
+// queued for the worker: synchronously unless SYNC_MODE is "async".
+func getSyncMode() string {
+	if os.Getenv("SYNC_MODE") == SyncModeAsync {
+		return SyncModeAsync
+	}
+	return SyncModeSync
+}
+
+// getFunctionRole returns what this Lambda serves: Shopify webhooks unless
+// FUNCTION_ROLE is "worker", in which case it consumes the sync queue.
+func getFunctionRole() string {
+	if os.Getenv("FUNCTION_ROLE") == FunctionRoleWorker {
+		return FunctionRoleWorker
+	}
+	return FunctionRoleWebhook
+}
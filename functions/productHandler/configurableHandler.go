@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/shopify"
+)
+
+// superAttribute is a Shopify option mapped onto a Magento attribute.
+type superAttribute struct {
+	position  int
+	label     string
+	attribute *magento.Attribute
+}
+
+// configurableParentSKU is the SKU of a product's configurable parent.
+func configurableParentSKU(product shopify.Product) string {
+	return limitSKU(product.Handle)
+}
+
+// getConfigurableParentPayload builds the configurable parent of a product,
+// keyed by the Shopify handle.
+func getConfigurableParentPayload(product shopify.Product, mapping *FieldMapping) magento.ProductRequest {
+	parent := magento.Product{
+		SKU:    configurableParentSKU(product),
+		Name:   product.Title,
+		TypeID: magento.TypeConfigurable,
+		ExtensionAttributes: &magento.ExtensionAttributes{
+			StockItem: &magento.StockItem{IsInStock: true},
+		},
+	}
+	applyFieldMapping(mapping, &parent, product, nil)
+	parent.SetCustomAttribute(getShopifyIDAttribute(), strconv.FormatInt(product.ID, 10))
+
+	return magento.ProductRequest{Product: parent}
+}
+
+// resolveSuperAttributes loads the Magento attribute of every Shopify option,
+// adding any option values Magento does not know yet.
+func resolveSuperAttributes(ctx context.Context, client *magento.Client, product shopify.Product) ([]superAttribute, error) {
+	attributes := []superAttribute{}
+
+	for _, option := range product.Options {
+		code := getOptionAttribute(option.Name)
+		attribute, err := client.GetAttribute(ctx, code)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load attribute %s for option %s: %w", code, option.Name, err)
+		}
+
+		added := false
+		for _, value := range option.Values {
+			if _, ok := attribute.OptionValue(value); ok {
+				continue
+			}
+			if err := client.AddAttributeOption(ctx, code, value); err != nil {
+				return nil, fmt.Errorf("failed to add %q to attribute %s: %w", value, code, err)
+			}
+			fmt.Printf("✅ Added option %q to attribute %s\n", value, code)
+			added = true
+		}
+
+		if added {
+			attribute, err = client.GetAttribute(ctx, code)
+			if err != nil {
+				return nil, fmt.Errorf("failed to reload attribute %s: %w", code, err)
+			}
+		}
+
+		attributes = append(attributes, superAttribute{
+			position:  option.Position,
+			label:     option.Name,
+			attribute: attribute,
+		})
+	}
+
+	return attributes, nil
+}
+
+// syncConfigurableProduct creates every variant as a child simple product,
+// upserts the configurable parent, then links the super attributes and the
+// children to it through the configurable-products API.
+func syncConfigurableProduct(ctx context.Context, client *magento.Client, product shopify.Product, parent magento.ProductRequest, payload []magento.ProductRequest, report *SyncReport) {
+
+	attributes, err := resolveSuperAttributes(ctx, client, product)
+	if err != nil {
+		fmt.Printf("❌ Error resolving super attributes: %v\n", err)
+		report.fail(err)
+		return
+	}
+
+	// Children are only reachable through the parent and carry one value per super attribute.
+	children := []magento.ProductRequest{}
+	for i, variant := range product.Variants {
+		child := payload[i]
+		child.Product.Visibility = magento.VisibilityNotVisible
+
+		valid := true
+		for _, sa := range attributes {
+			label := variant.OptionValue(sa.position)
+			value, ok := sa.attribute.OptionValue(label)
+			if !ok {
+				report.add(child.Product.SKU, SyncFailed, fmt.Errorf("no %s option for %q", sa.attribute.AttributeCode, label))
+				valid = false
+				break
+			}
+			child.Product.SetCustomAttribute(sa.attribute.AttributeCode, value)
+		}
+
+		if valid {
+			children = append(children, child)
+		}
+	}
+
+	records := mappingRecords(product, payload)
+	syncProducts(ctx, client, children, records, report)
+
+	status, err := manageProduct(ctx, client, parent, records[parent.Product.SKU])
+	report.add(parent.Product.SKU, status, err)
+	if err != nil {
+		fmt.Printf("❌ Failed to sync configurable parent %s: %v\n", parent.Product.SKU, err)
+		return
+	}
+
+	if err := linkConfigurableOptions(ctx, client, parent.Product.SKU, attributes, children); err != nil {
+		fmt.Printf("❌ Failed to link options to %s: %v\n", parent.Product.SKU, err)
+		report.fail(err)
+		return
+	}
+
+	if err := linkConfigurableChildren(ctx, client, parent.Product.SKU, children, report); err != nil {
+		fmt.Printf("❌ Failed to link children to %s: %v\n", parent.Product.SKU, err)
+		report.fail(err)
+	}
+}
+
+// linkConfigurableOptions adds the super attributes the parent does not have yet.
+func linkConfigurableOptions(ctx context.Context, client *magento.Client, parentSKU string, attributes []superAttribute, children []magento.ProductRequest) error {
+	existing, err := client.GetConfigurableOptions(ctx, parentSKU)
+	if err != nil {
+		return err
+	}
+
+	linked := map[string]bool{}
+	for _, option := range existing {
+		linked[option.AttributeID] = true
+	}
+
+	for _, sa := range attributes {
+		attributeID := strconv.Itoa(sa.attribute.AttributeID)
+		if linked[attributeID] {
+			continue
+		}
+
+		option := magento.ConfigurableOption{
+			AttributeID: attributeID,
+			Label:       sa.label,
+			Position:    sa.position - 1,
+		}
+
+		seen := map[int]bool{}
+		for _, child := range children {
+			value, _ := child.Product.CustomAttribute(sa.attribute.AttributeCode)
+			valueIndex, err := strconv.Atoi(fmt.Sprint(value))
+			if err != nil || seen[valueIndex] {
+				continue
+			}
+			seen[valueIndex] = true
+			option.Values = append(option.Values, magento.ConfigurableOptionValue{ValueIndex: valueIndex})
+		}
+
+		if err := client.AddConfigurableOption(ctx, parentSKU, option); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Linked attribute %s to %s\n", sa.attribute.AttributeCode, parentSKU)
+	}
+
+	return nil
+}
+
+// linkConfigurableChildren links every successfully synced child, including
+// unchanged ones, that is not linked to the parent yet.
+func linkConfigurableChildren(ctx context.Context, client *magento.Client, parentSKU string, children []magento.ProductRequest, report *SyncReport) error {
+	existing, err := client.GetConfigurableChildren(ctx, parentSKU)
+	if err != nil {
+		return err
+	}
+
+	linked := map[string]bool{}
+	for _, child := range existing {
+		linked[child.SKU] = true
+	}
+
+	synced := report.syncedSKUs()
+	for _, child := range children {
+		sku := child.Product.SKU
+		if linked[sku] || !synced[sku] {
+			continue
+		}
+		if err := client.AddConfigurableChild(ctx, parentSKU, sku); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Linked %s to %s\n", sku, parentSKU)
+	}
+
+	return nil
+}
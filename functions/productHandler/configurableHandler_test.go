@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"mokobara-middleware/shared/magento"
+)
+
+func TestLinkConfigurableChildren(t *testing.T) {
+	var linked []string
+	client := newTestMagento(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`[{"sku": "bag-black"}]`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		linked = append(linked, string(body))
+		w.Write([]byte(`true`))
+	})
+
+	children := []magento.ProductRequest{
+		{Product: magento.Product{SKU: "bag-black"}},
+		{Product: magento.Product{SKU: "bag-blue"}},
+		{Product: magento.Product{SKU: "bag-green"}},
+		{Product: magento.Product{SKU: "bag-red"}},
+	}
+	report := &SyncReport{}
+	report.add("bag-black", SyncUpdated, nil)
+	report.add("bag-blue", SyncUnchanged, nil)
+	report.add("bag-green", SyncCreated, nil)
+	report.add("bag-red", SyncFailed, io.ErrUnexpectedEOF)
+
+	if err := linkConfigurableChildren(context.Background(), client, "bag", children, report); err != nil {
+		t.Fatalf("linkConfigurableChildren() error = %v", err)
+	}
+
+	got := strings.Join(linked, "\n")
+	if len(linked) != 2 || !strings.Contains(got, "bag-blue") || !strings.Contains(got, "bag-green") {
+		t.Errorf("linked %v, want bag-blue and bag-green only", linked)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"mokobara-middleware/shared/apigw"
+	"mokobara-middleware/shared/dedup"
+)
+
+const (
+	webhookIDHeader   = "X-Shopify-Webhook-Id"
+	triggeredAtHeader = "X-Shopify-Triggered-At"
+)
+
+// webhookLease is how long a delivery holds its webhook ID while it is
+// processed. It outlasts the function timeout, so it only runs out on its own
+// when the invocation holding it crashed.
+const webhookLease = 5 * time.Minute
+
+// checkWebhook decides whether a delivery should be processed. Deliveries
+// whose webhook ID was completed before, and events triggered before the last
+// one processed for the same resource, are answered with a 200 so Shopify
+// stops sending them. A delivery whose webhook ID is still being processed
+// gets a 409, so Shopify retries it once the outcome is known. The dedup
+// store failing never blocks a sync.
+func checkWebhook(ctx context.Context, request events.APIGatewayV2HTTPRequest, resource string) (events.APIGatewayV2HTTPResponse, bool) {
+	store, err := getDedupStore()
+	if err != nil {
+		fmt.Printf("⚠️ Dedup store unavailable: %v\n", err)
+		return events.APIGatewayV2HTTPResponse{}, false
+	}
+
+	if webhookID := apigw.Header(request, webhookIDHeader); webhookID != "" {
+		claim, err := store.Claim(ctx, webhookID, webhookLease)
+		switch {
+		case err != nil:
+			fmt.Printf("⚠️ Error recording webhook %s: %v\n", webhookID, err)
+		case claim == dedup.Done:
+			fmt.Printf("🔁 Webhook %s already processed, ignoring\n", webhookID)
+			return ignoredWebhookResponse("Duplicate webhook ignored"), true
+		case claim == dedup.InProgress:
+			fmt.Printf("⏳ Webhook %s is being processed, asking for a retry\n", webhookID)
+			return busyWebhookResponse(), true
+		}
+	}
+
+	if triggeredAt := apigw.Header(request, triggeredAtHeader); triggeredAt != "" {
+		at, err := time.Parse(time.RFC3339Nano, triggeredAt)
+		if err != nil {
+			fmt.Printf("⚠️ Invalid %s header %q: %v\n", triggeredAtHeader, triggeredAt, err)
+			return events.APIGatewayV2HTTPResponse{}, false
+		}
+		current, err := store.Advance(ctx, resource, at, webhookTTL())
+		if err != nil {
+			fmt.Printf("⚠️ Error recording event time for %s: %v\n", resource, err)
+		} else if !current {
+			fmt.Printf("🔁 Event for %s triggered at %s is older than one already processed, ignoring\n", resource, triggeredAt)
+			completeWebhook(ctx, request)
+			return ignoredWebhookResponse("Stale webhook ignored"), true
+		}
+	}
+
+	return events.APIGatewayV2HTTPResponse{}, false
+}
+
+// finishWebhook answers a processed delivery and settles its webhook ID. When
+// the sync failed transiently the ID is released, so Shopify's retry is
+// processed; any other outcome completes it, so later deliveries are taken
+// for duplicates.
+func finishWebhook(ctx context.Context, request events.APIGatewayV2HTTPRequest, report *SyncReport) events.APIGatewayV2HTTPResponse {
+	if report.Transient() {
+		releaseWebhook(ctx, request)
+	} else {
+		completeWebhook(ctx, request)
+	}
+	return report.response()
+}
+
+// completeWebhook marks the webhook ID of request as processed.
+func completeWebhook(ctx context.Context, request events.APIGatewayV2HTTPRequest) {
+	webhookID := apigw.Header(request, webhookIDHeader)
+	store, err := getDedupStore()
+	if webhookID == "" || err != nil {
+		return
+	}
+	if err := store.Complete(context.WithoutCancel(ctx), webhookID, webhookTTL()); err != nil {
+		fmt.Printf("⚠️ Error completing webhook %s: %v\n", webhookID, err)
+	}
+}
+
+// releaseWebhook gives up the lease on the webhook ID of request.
+func releaseWebhook(ctx context.Context, request events.APIGatewayV2HTTPRequest) {
+	webhookID := apigw.Header(request, webhookIDHeader)
+	store, err := getDedupStore()
+	if webhookID == "" || err != nil {
+		return
+	}
+	if err := store.Release(context.WithoutCancel(ctx), webhookID); err != nil {
+		fmt.Printf("⚠️ Error releasing webhook %s: %v\n", webhookID, err)
+	}
+}
+
+// webhookTTL returns how long completed webhooks are remembered.
+func webhookTTL() time.Duration {
+	ttl, err := dedup.TTLFromEnv()
+	if err != nil {
+		fmt.Printf("⚠️ %v, using %v\n", err, dedup.DefaultTTL)
+		return dedup.DefaultTTL
+	}
+	return ttl
+}
+
+func productResource(productID int64) string {
+	return fmt.Sprintf("product:%d", productID)
+}
+
+func inventoryResource(inventoryItemID int64) string {
+	return fmt.Sprintf("inventory_item:%d", inventoryItemID)
+}
+
+func ignoredWebhookResponse(message string) events.APIGatewayV2HTTPResponse {
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: fmt.Sprintf(`{"message": "%s"}`, message),
+	}
+}
+
+func busyWebhookResponse() events.APIGatewayV2HTTPResponse {
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: 409,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: `{"error": "Webhook is already being processed"}`,
+	}
+}
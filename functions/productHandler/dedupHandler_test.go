@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func webhookRequest(webhookID string) events.APIGatewayV2HTTPRequest {
+	return events.APIGatewayV2HTTPRequest{Headers: map[string]string{"x-shopify-webhook-id": webhookID}}
+}
+
+func TestCheckWebhookLease(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		report     *SyncReport
+		wantStatus int
+	}{
+		{name: "success completes the webhook", report: &SyncReport{}, wantStatus: 200},
+		{name: "permanent failure completes the webhook", report: failedReport(errors.New("bad product")), wantStatus: 200},
+		{name: "transient failure releases the webhook", report: failedReport(context.DeadlineExceeded), wantStatus: 0},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := webhookRequest("lease-test-" + string(rune('a'+i)))
+
+			if response, ignored := checkWebhook(ctx, request, productResource(1)); ignored {
+				t.Fatalf("first delivery ignored with %d", response.StatusCode)
+			}
+
+			// A retry while the first delivery is still syncing.
+			response, ignored := checkWebhook(ctx, request, productResource(1))
+			if !ignored || response.StatusCode != 409 {
+				t.Fatalf("retry during the lease = %d, %v, want 409", response.StatusCode, ignored)
+			}
+
+			finishWebhook(ctx, request, tt.report)
+
+			response, ignored = checkWebhook(ctx, request, productResource(1))
+			if tt.wantStatus == 0 {
+				if ignored {
+					t.Errorf("retry after a transient failure ignored with %d, want it processed", response.StatusCode)
+				}
+				return
+			}
+			if !ignored || response.StatusCode != tt.wantStatus {
+				t.Errorf("retry after finishing = %d, %v, want %d", response.StatusCode, ignored, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func failedReport(err error) *SyncReport {
+	report := &SyncReport{}
+	report.fail(err)
+	return report
+}
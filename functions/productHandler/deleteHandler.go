@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"mokobara-middleware/shared/deadline"
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/retry"
+)
+
+// findProductSKUs returns the Magento SKUs derived from a Shopify product.
+// The products/delete webhook only carries the product ID, so the SKUs come
+// from the mapping store and from a search on the Shopify ID attribute
+// written on every sync, which also covers SKUs synced before the store
+// existed. A search that fails for good, e.g. because the attribute is
+// missing, is only returned when the mapping store knows no SKU either.
+func findProductSKUs(ctx context.Context, client *magento.Client, store mapping.Store, productID int64) ([]string, error) {
+	skus := []string{}
+	seen := map[string]bool{}
+	add := func(sku string) {
+		if sku != "" && !seen[sku] {
+			seen[sku] = true
+			skus = append(skus, sku)
+		}
+	}
+
+	records, storeErr := store.FindByProduct(ctx, productID)
+	if storeErr != nil {
+		fmt.Printf("⚠️ Error reading mappings of product %d: %v\n", productID, storeErr)
+	}
+	for _, record := range records {
+		add(record.MagentoSKU)
+	}
+
+	products, err := client.SearchProducts(ctx, magento.SearchCriteria{
+		Filters: []magento.Filter{
+			{Field: getShopifyIDAttribute(), Value: strconv.FormatInt(productID, 10)},
+		},
+	})
+	if err != nil {
+		if retry.IsTransient(err) || len(skus) == 0 {
+			return nil, err
+		}
+		fmt.Printf("⚠️ Error searching Magento by %s, using the mapping store only: %v\n", getShopifyIDAttribute(), err)
+	}
+	for _, p := range products {
+		add(p.SKU)
+	}
+
+	if len(skus) == 0 && storeErr != nil {
+		return nil, storeErr
+	}
+	return skus, nil
+}
+
+// deleteProductHandler disables or deletes every Magento SKU derived from a
+// product deleted in Shopify, depending on PRODUCT_DELETE_MODE.
+func deleteProductHandler(ctx context.Context, productID int64) *SyncReport {
+	report := &SyncReport{ProductID: productID}
+
+	ctx, cancel := deadline.WithMargin(ctx, deadline.DefaultMargin)
+	defer cancel()
+
+	client, err := getMagentoClient()
+	if err != nil {
+		fmt.Printf("❌ Error configuring Magento client: %v\n", err)
+		report.failConfig(err)
+		return report
+	}
+
+	store, err := getMappingStore()
+	if err != nil {
+		fmt.Printf("❌ Error configuring mapping store: %v\n", err)
+		report.failConfig(err)
+		return report
+	}
+
+	skus, err := findProductSKUs(ctx, client, store, productID)
+	if err != nil {
+		fmt.Printf("❌ Error finding Magento products: %v\n", err)
+		report.fail(err)
+		return report
+	}
+
+	if len(skus) == 0 {
+		fmt.Printf("🔥 No Magento products found for Shopify product %d\n", productID)
+	}
+
+	mode := getDeleteMode()
+	for _, sku := range skus {
+		if mode == DeleteModeDelete {
+			err = client.DeleteProduct(ctx, sku)
+			report.add(sku, SyncDeleted, err)
+		} else {
+			err = client.SetProductStatus(ctx, sku, magento.StatusDisabled)
+			report.add(sku, SyncDisabled, err)
+		}
+
+		if err != nil {
+			fmt.Printf("❌ Failed to %s product %s: %v\n", mode, sku, err)
+			continue
+		}
+		fmt.Printf("✅ Product %s: %sd\n", sku, mode)
+	}
+
+	return report
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+)
+
+// newTestMagento returns a Magento client whose requests are answered by
+// handler.
+func newTestMagento(t *testing.T, handler http.HandlerFunc) *magento.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := magento.NewClient(magento.Config{BaseURL: server.URL, Token: "token", HTTPClient: server.Client()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestFindProductSKUs(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		stored  []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "search and mapping store are merged",
+			status: http.StatusOK,
+			body:   `{"items": [{"sku": "bag-black"}, {"sku": "bag-legacy"}], "total_count": 2}`,
+			stored: []string{"bag-black", "bag-blue"},
+			want:   []string{"bag-black", "bag-blue", "bag-legacy"},
+		},
+		{
+			name:   "missing attribute falls back to the mapping store",
+			status: http.StatusBadRequest,
+			body:   `{"message": "Invalid attribute name: shopify_product_id"}`,
+			stored: []string{"bag-black"},
+			want:   []string{"bag-black"},
+		},
+		{
+			name:    "missing attribute without mappings fails",
+			status:  http.StatusBadRequest,
+			body:    `{"message": "Invalid attribute name: shopify_product_id"}`,
+			wantErr: true,
+		},
+		{
+			name:    "transient search failure fails",
+			status:  http.StatusServiceUnavailable,
+			body:    `{"message": "unavailable"}`,
+			stored:  []string{"bag-black"},
+			wantErr: true,
+		},
+		{
+			name:   "nothing found",
+			status: http.StatusOK,
+			body:   `{"items": [], "total_count": 0}`,
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestMagento(t, func(w http.ResponseWriter, r *http.Request) {
+				if !strings.Contains(r.URL.RawQuery, "shopify_product_id") {
+					t.Errorf("search query %q does not filter on the Shopify ID attribute", r.URL.RawQuery)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			store := mapping.NewMemoryStore()
+			for i, sku := range tt.stored {
+				store.Put(ctx, mapping.Record{Kind: mapping.KindVariant, Key: sku, ShopifyID: int64(i), ShopifyProductID: 7, MagentoSKU: sku})
+			}
+			store.Put(ctx, mapping.Record{Kind: mapping.KindVariant, Key: "other", ShopifyProductID: 8, MagentoSKU: "wallet"})
+
+			got, err := findProductSKUs(ctx, client, store, 7)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findProductSKUs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("findProductSKUs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/shopify"
+)
+
+// defaultFieldMapping is used unless FIELD_MAPPING_PATH points at another file.
+//
+//go:embed mapping.json
+var defaultFieldMapping []byte
+
+// getFieldMapping returns the mapping loaded at cold start.
+var getFieldMapping = sync.OnceValues(loadFieldMapping)
+
+// FieldMapping declares how Shopify data becomes Magento attributes.
+type FieldMapping struct {
+	Defaults   MappingDefaults    `json:"defaults"`
+	Attributes []AttributeMapping `json:"attributes"`
+
+	// Metafields maps "<namespace>.<key>" to the Magento attribute the
+	// metafield is copied into, converted with shopify.Metafield.Text.
+	Metafields map[string]string `json:"metafields"`
+}
+
+// MappingDefaults are the fixed values sent for every product. Weight, in the
+// store's weight unit, is only used for variants without a Shopify weight.
+type MappingDefaults struct {
+	AttributeSetID int     `json:"attribute_set_id"`
+	Weight         float64 `json:"weight"`
+	Visibility     int     `json:"visibility"`
+	Status         int     `json:"status"`
+}
+
+// AttributeMapping fills one Magento attribute from a Shopify source:
+//
+//	product.<field>                  e.g. product.vendor, product.body_html
+//	variant.<field>                  e.g. variant.barcode
+//	option.<name>                    the variant's value for the named option
+//	tag.<prefix>                     the value of a "<prefix>:<value>" tag
+//	metafield.<namespace>.<key>      a product metafield
+type AttributeMapping struct {
+	Attribute  string      `json:"attribute"`
+	Source     string      `json:"source"`
+	Transforms []Transform `json:"transforms"`
+}
+
+// Transform is applied to a source value in order:
+//
+//	{"type": "lowercase"} / {"type": "uppercase"} / {"type": "trim"}
+//	{"type": "lookup", "table": {"navy": "blue"}}   unmatched values pass through
+//	{"type": "default", "value": "n/a"}             used when the value is empty
+type Transform struct {
+	Type  string            `json:"type"`
+	Table map[string]string `json:"table,omitempty"`
+	Value string            `json:"value,omitempty"`
+}
+
+var sourceKinds = []string{"product", "variant", "option", "tag", "metafield"}
+
+func loadFieldMapping() (*FieldMapping, error) {
+	data := defaultFieldMapping
+	if path := os.Getenv("FIELD_MAPPING_PATH"); path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read field mapping: %w", err)
+		}
+	}
+
+	var mapping FieldMapping
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse field mapping: %w", err)
+	}
+	if err := mapping.Validate(); err != nil {
+		return nil, err
+	}
+	return &mapping, nil
+}
+
+// Validate rejects mappings with unknown sources or transforms, so a bad file
+// fails the cold start instead of silently dropping attributes. Missing status
+// and visibility default to enabled and visible in catalog and search.
+func (m *FieldMapping) Validate() error {
+	problems := []string{}
+
+	if m.Defaults.AttributeSetID <= 0 {
+		problems = append(problems, "defaults.attribute_set_id is required")
+	}
+	if m.Defaults.Status == 0 {
+		m.Defaults.Status = magento.StatusEnabled
+	}
+	if m.Defaults.Visibility == 0 {
+		m.Defaults.Visibility = magento.VisibilityBoth
+	}
+
+	for i, attr := range m.Attributes {
+		if attr.Attribute == "" {
+			problems = append(problems, fmt.Sprintf("attributes[%d]: attribute is required", i))
+		}
+		kind, _, _ := strings.Cut(attr.Source, ".")
+		if !slices.Contains(sourceKinds, kind) {
+			problems = append(problems, fmt.Sprintf("attributes[%d]: unknown source %q", i, attr.Source))
+		}
+		for j, t := range attr.Transforms {
+			switch t.Type {
+			case "lowercase", "uppercase", "trim", "default":
+			case "lookup":
+				if len(t.Table) == 0 {
+					problems = append(problems, fmt.Sprintf("attributes[%d].transforms[%d]: lookup needs a table", i, j))
+				}
+			default:
+				problems = append(problems, fmt.Sprintf("attributes[%d].transforms[%d]: unknown transform %q", i, j, t.Type))
+			}
+		}
+	}
+
+	for key, attribute := range m.Metafields {
+		if _, _, ok := strings.Cut(key, "."); !ok {
+			problems = append(problems, fmt.Sprintf("metafields[%q]: key must be <namespace>.<key>", key))
+		}
+		if attribute == "" {
+			problems = append(problems, fmt.Sprintf("metafields[%q]: attribute is required", key))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid field mapping: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// VariantSpecific reports whether the source depends on a variant.
+func (a AttributeMapping) VariantSpecific() bool {
+	kind, _, _ := strings.Cut(a.Source, ".")
+	return kind == "variant" || kind == "option"
+}
+
+// Resolve reads the source value for a product, or for one of its variants if
+// variant is non-nil, and applies the transforms.
+func (a AttributeMapping) Resolve(product shopify.Product, variant *shopify.Variant) string {
+	value := resolveSource(a.Source, product, variant)
+	for _, t := range a.Transforms {
+		value = t.apply(value)
+	}
+	return value
+}
+
+func (t Transform) apply(value string) string {
+	switch t.Type {
+	case "lowercase":
+		return strings.ToLower(value)
+	case "uppercase":
+		return strings.ToUpper(value)
+	case "trim":
+		return strings.TrimSpace(value)
+	case "lookup":
+		if mapped, ok := t.Table[value]; ok {
+			return mapped
+		}
+	case "default":
+		if value == "" {
+			return t.Value
+		}
+	}
+	return value
+}
+
+func resolveSource(source string, product shopify.Product, variant *shopify.Variant) string {
+	kind, name, _ := strings.Cut(source, ".")
+
+	switch kind {
+	case "product":
+		return productField(product, name)
+
+	case "variant":
+		if variant == nil {
+			return ""
+		}
+		return variantField(*variant, name)
+
+	case "option":
+		if variant == nil {
+			return ""
+		}
+		for _, option := range product.Options {
+			if strings.EqualFold(option.Name, name) {
+				return variant.OptionValue(option.Position)
+			}
+		}
+
+	case "tag":
+		for _, tag := range strings.Split(product.Tags, ",") {
+			prefix, value, ok := strings.Cut(strings.TrimSpace(tag), ":")
+			if ok && strings.EqualFold(strings.TrimSpace(prefix), name) {
+				return strings.TrimSpace(value)
+			}
+		}
+
+	case "metafield":
+		for _, mf := range product.Metafields {
+			if mf.FullKey() == name {
+				value, err := mf.Text()
+				if err != nil {
+					fmt.Printf("❌ Error converting metafield %s: %v\n", name, err)
+				}
+				return value
+			}
+		}
+	}
+
+	return ""
+}
+
+func productField(product shopify.Product, field string) string {
+	switch field {
+	case "id":
+		return strconv.FormatInt(product.ID, 10)
+	case "title":
+		return product.Title
+	case "body_html":
+		return product.BodyHTML
+	case "vendor":
+		return product.Vendor
+	case "product_type":
+		return product.ProductType
+	case "handle":
+		return product.Handle
+	case "tags":
+		return product.Tags
+	}
+	return ""
+}
+
+func variantField(variant shopify.Variant, field string) string {
+	switch field {
+	case "id":
+		return strconv.FormatInt(variant.ID, 10)
+	case "title":
+		return variant.Title
+	case "sku":
+		return variant.SKU
+	case "barcode":
+		return variant.Barcode
+	case "price":
+		return variant.Price
+	case "compare_at_price":
+		return variant.CompareAtPrice
+	case "weight":
+		return strconv.FormatFloat(variant.Weight, 'f', -1, 64)
+	case "weight_unit":
+		return variant.WeightUnit
+	}
+	return ""
+}
+
+// applyFieldMapping sets the mapped defaults and custom attributes on a
+// Magento product. Variant-specific sources are skipped when variant is nil,
+// e.g. for a configurable parent.
+func applyFieldMapping(mapping *FieldMapping, product *magento.Product, source shopify.Product, variant *shopify.Variant) {
+	product.AttributeSetID = mapping.Defaults.AttributeSetID
+	product.Weight = mapping.Defaults.Weight
+	product.Visibility = mapping.Defaults.Visibility
+	product.Status = mapping.Defaults.Status
+
+	for _, attr := range mapping.Attributes {
+		if variant == nil && attr.VariantSpecific() {
+			continue
+		}
+		product.SetCustomAttribute(attr.Attribute, attr.Resolve(source, variant))
+	}
+
+	for _, mf := range source.Metafields {
+		attribute, ok := mapping.Metafields[mf.FullKey()]
+		if !ok {
+			continue
+		}
+		value, err := mf.Text()
+		if err != nil {
+			fmt.Printf("❌ Error converting metafield %s: %v\n", mf.FullKey(), err)
+			continue
+		}
+		product.SetCustomAttribute(attribute, value)
+	}
+}
+
+// logUnmappedMetafields reports metafields that no mapping entry uses, so new
+// merchant data can be noticed and mapped.
+func logUnmappedMetafields(mapping *FieldMapping, product shopify.Product) {
+	used := map[string]bool{}
+	for _, attr := range mapping.Attributes {
+		if name, ok := strings.CutPrefix(attr.Source, "metafield."); ok {
+			used[name] = true
+		}
+	}
+
+	for _, mf := range product.Metafields {
+		key := mf.FullKey()
+		if mf.Key == publishedMetafield {
+			continue
+		}
+		if _, ok := mapping.Metafields[key]; ok || used[key] {
+			continue
+		}
+		fmt.Printf("⚠️ Unmapped metafield %s (%s) on product %d\n", key, mf.Type, product.ID)
+	}
+}
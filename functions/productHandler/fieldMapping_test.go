@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/shopify"
+)
+
+func TestTransformApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform Transform
+		value     string
+		want      string
+	}{
+		{name: "lowercase", transform: Transform{Type: "lowercase"}, value: "Navy Blue", want: "navy blue"},
+		{name: "uppercase", transform: Transform{Type: "uppercase"}, value: "Navy Blue", want: "NAVY BLUE"},
+		{name: "trim", transform: Transform{Type: "trim"}, value: "  navy \n", want: "navy"},
+		{name: "lookup match", transform: Transform{Type: "lookup", Table: map[string]string{"navy": "blue"}}, value: "navy", want: "blue"},
+		{name: "lookup passes unmatched values", transform: Transform{Type: "lookup", Table: map[string]string{"navy": "blue"}}, value: "red", want: "red"},
+		{name: "lookup is case-sensitive", transform: Transform{Type: "lookup", Table: map[string]string{"navy": "blue"}}, value: "Navy", want: "Navy"},
+		{name: "default fills empty values", transform: Transform{Type: "default", Value: "n/a"}, value: "", want: "n/a"},
+		{name: "default keeps values", transform: Transform{Type: "default", Value: "n/a"}, value: "leather", want: "leather"},
+		{name: "unknown type passes through", transform: Transform{Type: "reverse"}, value: "abc", want: "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.transform.apply(tt.value); got != tt.want {
+				t.Errorf("apply(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttributeMappingResolve(t *testing.T) {
+	product := shopify.Product{
+		ID:       7,
+		Title:    "Bag",
+		Vendor:   "Mokobara",
+		Tags:     "sale, Material: Leather ,Color:navy",
+		Options:  []shopify.Option{{Name: "Size", Position: 1}, {Name: "Color", Position: 2}},
+		Variants: []shopify.Variant{{ID: 11, SKU: "BAG-01", Barcode: "890", Weight: 1.5, Option1: "Large", Option2: "Navy"}},
+		Metafields: []shopify.Metafield{
+			{Namespace: "custom", Key: "material", Type: "single_line_text_field", Value: json.RawMessage(`"leather"`)},
+			{Namespace: "custom", Key: "waterproof", Type: "boolean", Value: json.RawMessage(`true`)},
+		},
+	}
+	variant := &product.Variants[0]
+
+	tests := []struct {
+		name    string
+		mapping AttributeMapping
+		variant *shopify.Variant
+		want    string
+	}{
+		{name: "product field", mapping: AttributeMapping{Source: "product.vendor"}, want: "Mokobara"},
+		{name: "product id", mapping: AttributeMapping{Source: "product.id"}, want: "7"},
+		{name: "unknown product field", mapping: AttributeMapping{Source: "product.color"}, want: ""},
+		{name: "variant field", mapping: AttributeMapping{Source: "variant.barcode"}, variant: variant, want: "890"},
+		{name: "variant weight", mapping: AttributeMapping{Source: "variant.weight"}, variant: variant, want: "1.5"},
+		{name: "variant field without a variant", mapping: AttributeMapping{Source: "variant.sku"}, want: ""},
+		{name: "option by name", mapping: AttributeMapping{Source: "option.color"}, variant: variant, want: "Navy"},
+		{name: "missing option", mapping: AttributeMapping{Source: "option.material"}, variant: variant, want: ""},
+		{name: "tag prefix", mapping: AttributeMapping{Source: "tag.material"}, want: "Leather"},
+		{name: "tag without the prefix", mapping: AttributeMapping{Source: "tag.style"}, want: ""},
+		{name: "metafield", mapping: AttributeMapping{Source: "metafield.custom.material"}, want: "leather"},
+		{name: "metafield converted", mapping: AttributeMapping{Source: "metafield.custom.waterproof"}, want: "1"},
+		{
+			name:    "transforms applied in order",
+			mapping: AttributeMapping{Source: "tag.color", Transforms: []Transform{{Type: "uppercase"}, {Type: "lookup", Table: map[string]string{"NAVY": "Blue"}}}},
+			want:    "Blue",
+		},
+		{
+			name:    "default after an empty source",
+			mapping: AttributeMapping{Source: "tag.style", Transforms: []Transform{{Type: "trim"}, {Type: "default", Value: "classic"}}},
+			want:    "classic",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mapping.Resolve(product, tt.variant); got != tt.want {
+				t.Errorf("Resolve(%s) = %q, want %q", tt.mapping.Source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldMappingValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping FieldMapping
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			mapping: FieldMapping{Defaults: MappingDefaults{AttributeSetID: 4}, Attributes: []AttributeMapping{{Attribute: "color", Source: "option.color", Transforms: []Transform{{Type: "lookup", Table: map[string]string{"navy": "blue"}}}}}},
+		},
+		{name: "missing attribute set", mapping: FieldMapping{}, wantErr: "attribute_set_id"},
+		{name: "unknown source", mapping: FieldMapping{Defaults: MappingDefaults{AttributeSetID: 4}, Attributes: []AttributeMapping{{Attribute: "color", Source: "collection.title"}}}, wantErr: "unknown source"},
+		{name: "lookup without table", mapping: FieldMapping{Defaults: MappingDefaults{AttributeSetID: 4}, Attributes: []AttributeMapping{{Attribute: "color", Source: "tag.color", Transforms: []Transform{{Type: "lookup"}}}}}, wantErr: "lookup needs a table"},
+		{name: "unknown transform", mapping: FieldMapping{Defaults: MappingDefaults{AttributeSetID: 4}, Attributes: []AttributeMapping{{Attribute: "color", Source: "tag.color", Transforms: []Transform{{Type: "reverse"}}}}}, wantErr: "unknown transform"},
+		{name: "metafield key without namespace", mapping: FieldMapping{Defaults: MappingDefaults{AttributeSetID: 4}, Metafields: map[string]string{"material": "material"}}, wantErr: "<namespace>.<key>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.mapping.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				if tt.mapping.Defaults.Status != magento.StatusEnabled || tt.mapping.Defaults.Visibility != magento.VisibilityBoth {
+					t.Errorf("Validate() defaults = %+v, want enabled and visible", tt.mapping.Defaults)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyFieldMappingSkipsVariantSources(t *testing.T) {
+	mapping := &FieldMapping{
+		Defaults: MappingDefaults{AttributeSetID: 4, Weight: 1, Visibility: magento.VisibilityBoth, Status: magento.StatusEnabled},
+		Attributes: []AttributeMapping{
+			{Attribute: "manufacturer", Source: "product.vendor"},
+			{Attribute: "barcode", Source: "variant.barcode"},
+		},
+		Metafields: map[string]string{"custom.material": "material"},
+	}
+	source := shopify.Product{
+		Vendor:     "Mokobara",
+		Variants:   []shopify.Variant{{Barcode: "890"}},
+		Metafields: []shopify.Metafield{{Namespace: "custom", Key: "material", Type: "single_line_text_field", Value: json.RawMessage(`"leather"`)}},
+	}
+
+	var parent magento.Product
+	applyFieldMapping(mapping, &parent, source, nil)
+	if _, ok := parent.CustomAttribute("barcode"); ok {
+		t.Error("parent got the variant-specific barcode")
+	}
+	if value, _ := parent.CustomAttribute("manufacturer"); value != "Mokobara" {
+		t.Errorf("parent manufacturer = %v, want Mokobara", value)
+	}
+
+	var child magento.Product
+	applyFieldMapping(mapping, &child, source, &source.Variants[0])
+	if value, _ := child.CustomAttribute("barcode"); value != "890" {
+		t.Errorf("child barcode = %v, want 890", value)
+	}
+	if value, _ := child.CustomAttribute("material"); value != "leather" {
+		t.Errorf("child material = %v, want leather", value)
+	}
+	if child.AttributeSetID != 4 || child.Weight != 1 {
+		t.Errorf("child defaults = set %d, weight %v, want 4, 1", child.AttributeSetID, child.Weight)
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/retry"
+	"mokobara-middleware/shared/shopify"
+)
+
+// imageHashLength is how many hex characters of the content hash are used as
+// the uploaded file name, which is how already uploaded images are recognised.
+const imageHashLength = 16
+
+// maxImageSize guards the Lambda against unexpectedly large downloads.
+const maxImageSize = 20 << 20
+
+var getImageHTTPClient = sync.OnceValue(retry.NewClient)
+
+// productImage is a Shopify image and its content hash. When the hash is
+// remembered from an earlier sync the content is only downloaded if it has
+// to be uploaded.
+type productImage struct {
+	image shopify.Image
+	hash  string
+
+	once        sync.Once
+	err         error
+	contentType string
+	data        []byte
+}
+
+// load downloads the image content unless it already was. A download whose
+// hash differs from the remembered one fails, since the gallery was matched
+// against the old content.
+func (i *productImage) load(ctx context.Context) error {
+	i.once.Do(func() {
+		if i.data != nil {
+			return
+		}
+		downloaded, err := downloadImage(ctx, i.image)
+		if err != nil {
+			i.err = err
+			return
+		}
+		if downloaded.hash != i.hash {
+			forgetImage(ctx, i.image)
+			i.err = fmt.Errorf("image %d changed since it was last downloaded", i.image.ID)
+			return
+		}
+		i.contentType, i.data = downloaded.contentType, downloaded.data
+	})
+	return i.err
+}
+
+// fileName names the upload after the content hash so it can be matched
+// against the gallery on later syncs.
+func (i *productImage) fileName() string {
+	extension := ".jpg"
+	switch i.contentType {
+	case "image/png":
+		extension = ".png"
+	case "image/gif":
+		extension = ".gif"
+	case "image/webp":
+		extension = ".webp"
+	}
+	return i.hash[:imageHashLength] + extension
+}
+
+// imageAssignment places one Shopify image in a SKU's gallery.
+type imageAssignment struct {
+	imageID  int64
+	position int
+	roles    []string
+}
+
+// downloadImage fetches an image from the Shopify CDN and hashes its content.
+func downloadImage(ctx context.Context, image shopify.Image) (*productImage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, image.Src, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image request: %w", err)
+	}
+
+	resp, err := getImageHTTPClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download image %d: %w", image.ID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &shopify.Error{Method: http.MethodGet, Path: image.Src, StatusCode: resp.StatusCode}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %d: %w", image.ID, err)
+	}
+	if len(data) > maxImageSize {
+		return nil, fmt.Errorf("image %d is larger than %d bytes", image.ID, maxImageSize)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		contentType = http.DetectContentType(data)
+	}
+
+	sum := sha256.Sum256(data)
+	return &productImage{
+		image:       image,
+		hash:        hex.EncodeToString(sum[:]),
+		contentType: contentType,
+		data:        data,
+	}, nil
+}
+
+// imageRecord is the mapping record of a Shopify image.
+func imageRecord(image shopify.Image) mapping.Record {
+	return mapping.Record{
+		Kind:      mapping.KindImage,
+		Key:       strconv.FormatInt(image.ID, 10),
+		ShopifyID: image.ID,
+		Source:    image.Src,
+	}
+}
+
+// getProductImage returns the image with its content hash, downloading it
+// only when the hash of its current source is not remembered yet.
+func getProductImage(ctx context.Context, store mapping.Store, image shopify.Image) (*productImage, error) {
+	record := imageRecord(image)
+	if store != nil {
+		known, err := knownProduct(ctx, store, record)
+		if err != nil {
+			fmt.Printf("⚠️ Error reading mapping of image %d: %v\n", image.ID, err)
+		}
+		if known != nil && known.Source == image.Src && known.Hash != "" {
+			return &productImage{image: image, hash: known.Hash}, nil
+		}
+	}
+
+	downloaded, err := downloadImage(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+	if store != nil {
+		record.Hash = downloaded.hash
+		if err := store.Put(ctx, record); err != nil {
+			fmt.Printf("⚠️ Error storing mapping of image %d: %v\n", image.ID, err)
+		}
+	}
+	return downloaded, nil
+}
+
+// forgetImage drops the remembered hash of image so the next sync downloads
+// it again.
+func forgetImage(ctx context.Context, image shopify.Image) {
+	store, err := getMappingStore()
+	if err != nil {
+		return
+	}
+	if err := store.Delete(ctx, mapping.KindImage, strconv.FormatInt(image.ID, 10)); err != nil {
+		fmt.Printf("⚠️ Error clearing mapping of image %d: %v\n", image.ID, err)
+	}
+}
+
+// sortedImages returns the product images in Shopify's display order.
+func sortedImages(product shopify.Product) []shopify.Image {
+	images := slices.Clone(product.Images)
+	sort.SliceStable(images, func(i, j int) bool { return images[i].Position < images[j].Position })
+	return images
+}
+
+// imagesForParent assigns every product image, the first one as main image.
+func imagesForParent(product shopify.Product) []imageAssignment {
+	assignments := []imageAssignment{}
+	for i, image := range sortedImages(product) {
+		assignment := imageAssignment{imageID: image.ID, position: i + 1}
+		if i == 0 {
+			assignment.roles = magento.MainImageRoles
+		}
+		assignments = append(assignments, assignment)
+	}
+	return assignments
+}
+
+// imagesForVariant assigns the variant's own image as main image followed by
+// the shared product images. Images attached only to other variants are left out.
+func imagesForVariant(product shopify.Product, variant shopify.Variant) []imageAssignment {
+	images := []shopify.Image{}
+	for _, image := range sortedImages(product) {
+		if image.ID == variant.ImageID {
+			images = append([]shopify.Image{image}, images...)
+			continue
+		}
+		if len(image.VariantIDs) == 0 {
+			images = append(images, image)
+		}
+	}
+
+	assignments := []imageAssignment{}
+	for i, image := range images {
+		assignment := imageAssignment{imageID: image.ID, position: i + 1}
+		if i == 0 {
+			assignment.roles = magento.MainImageRoles
+		}
+		assignments = append(assignments, assignment)
+	}
+	return assignments
+}
+
+// imageTargets decides the gallery of every SKU synced in this run.
+func imageTargets(product shopify.Product, payload []magento.ProductRequest, configurable bool, report *SyncReport) map[string][]imageAssignment {
+	synced := report.syncedSKUs()
+
+	targets := map[string][]imageAssignment{}
+	for i, variant := range product.Variants {
+		if sku := payload[i].Product.SKU; synced[sku] {
+			targets[sku] = imagesForVariant(product, variant)
+		}
+	}
+
+	if configurable {
+		if sku := configurableParentSKU(product); synced[sku] {
+			targets[sku] = imagesForParent(product)
+		}
+	}
+	return targets
+}
+
+// galleryHash identifies the gallery assignments give a SKU. Images are
+// identified by their source, so replacing one changes the hash.
+func galleryHash(product shopify.Product, assignments []imageAssignment) (string, error) {
+	sources := map[int64]string{}
+	for _, image := range product.Images {
+		sources[image.ID] = image.Src
+	}
+
+	type galleryImage struct {
+		Src      string   `json:"src"`
+		Position int      `json:"position"`
+		Roles    []string `json:"roles"`
+	}
+	gallery := []galleryImage{}
+	for _, assignment := range assignments {
+		gallery = append(gallery, galleryImage{sources[assignment.imageID], assignment.position, assignment.roles})
+	}
+	return contentHash(gallery)
+}
+
+// syncImages uploads the product images into the Magento media gallery of
+// every target SKU. Galleries whose hash matches the last synced one are left
+// alone unless the SKU was just created. Each image is downloaded at most once
+// per run, and only if it is new, its source changed or a gallery is missing it.
+func syncImages(ctx context.Context, client *magento.Client, product shopify.Product, targets map[string][]imageAssignment, report *SyncReport) {
+	if len(targets) == 0 || len(product.Images) == 0 {
+		return
+	}
+
+	store, err := getMappingStore()
+	if err != nil {
+		fmt.Printf("⚠️ Mapping store unavailable, downloading every image: %v\n", err)
+	}
+
+	created := report.skusWith(SyncCreated)
+	galleries := map[string]mapping.Record{}
+	for sku, assignments := range targets {
+		hash, err := galleryHash(product, assignments)
+		if err != nil {
+			fmt.Printf("⚠️ Error hashing gallery of %s: %v\n", sku, err)
+		}
+		record := contentRecord(mapping.KindGallery, sku, product.ID, sku, hash)
+		if !created[sku] && hash != "" && contentSynced(ctx, store, record) {
+			fmt.Printf("✅ Images unchanged since last sync: %s\n", sku)
+			continue
+		}
+		galleries[sku] = record
+	}
+	if len(galleries) == 0 {
+		return
+	}
+
+	images := map[int64]*productImage{}
+	for _, image := range product.Images {
+		downloaded, err := getProductImage(ctx, store, image)
+		if err != nil {
+			fmt.Printf("❌ Error downloading image %d: %v\n", image.ID, err)
+			continue
+		}
+		images[image.ID] = downloaded
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for sku, record := range galleries {
+		wg.Add(1)
+		go func(sku string, record mapping.Record) {
+			defer wg.Done()
+			if err := syncSKUImages(ctx, client, sku, targets[sku], images); err != nil {
+				fmt.Printf("❌ Error syncing images for %s: %v\n", sku, err)
+				mu.Lock()
+				report.imageFailed(sku, err)
+				mu.Unlock()
+				return
+			}
+			if store != nil && record.Hash != "" {
+				rememberProduct(ctx, store, record, nil)
+			}
+		}(sku, record)
+	}
+
+	wg.Wait()
+}
+
+// syncSKUImages uploads the images missing from one SKU's gallery and fixes
+// the position and roles of the ones already there.
+func syncSKUImages(ctx context.Context, client *magento.Client, sku string, assignments []imageAssignment, images map[int64]*productImage) error {
+	entries, err := client.GetMediaEntries(ctx, sku)
+	if err != nil {
+		return err
+	}
+
+	byHash := map[string]magento.MediaGalleryEntry{}
+	for _, entry := range entries {
+		name := entry.FileName()
+		if len(name) >= imageHashLength {
+			byHash[name[:imageHashLength]] = entry
+		}
+	}
+
+	for _, assignment := range assignments {
+		image, ok := images[assignment.imageID]
+		if !ok {
+			return fmt.Errorf("image %d could not be downloaded", assignment.imageID)
+		}
+
+		roles := assignment.roles
+		if roles == nil {
+			roles = []string{}
+		}
+
+		if entry, ok := byHash[image.hash[:imageHashLength]]; ok {
+			if entry.Position == assignment.position && sameRoles(entry.Types, roles) {
+				continue
+			}
+			entry.Position = assignment.position
+			entry.Types = roles
+			if err := client.UpdateMediaEntry(ctx, sku, entry); err != nil {
+				return err
+			}
+			fmt.Printf("✅ Updated image %s on %s\n", entry.File, sku)
+			continue
+		}
+
+		if err := image.load(ctx); err != nil {
+			return err
+		}
+		entry := magento.MediaGalleryEntry{
+			MediaType: "image",
+			Label:     image.image.Alt,
+			Position:  assignment.position,
+			Types:     roles,
+			Content: &magento.ImageContent{
+				Base64EncodedData: base64.StdEncoding.EncodeToString(image.data),
+				Type:              image.contentType,
+				Name:              image.fileName(),
+			},
+		}
+		if _, err := client.AddMediaEntry(ctx, sku, entry); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Uploaded image %s to %s\n", image.fileName(), sku)
+	}
+
+	return nil
+}
+
+func sameRoles(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
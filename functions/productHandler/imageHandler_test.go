@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/shopify"
+)
+
+// newImageServer serves body as a PNG and counts the downloads.
+func newImageServer(t *testing.T, body []byte) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &downloads
+}
+
+func TestDownloadImageSizeLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		wantErr bool
+	}{
+		{name: "at the limit", size: maxImageSize},
+		{name: "over the limit", size: maxImageSize + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newImageServer(t, bytes.Repeat([]byte{1}, tt.size))
+
+			image, err := downloadImage(context.Background(), shopify.Image{ID: 1, Src: server.URL})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("downloadImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(image.data) != tt.size {
+				t.Errorf("downloadImage() read %d bytes, want %d", len(image.data), tt.size)
+			}
+		})
+	}
+}
+
+func TestGetProductImageRemembersHash(t *testing.T) {
+	ctx := context.Background()
+	server, downloads := newImageServer(t, []byte("png"))
+	store := mapping.NewMemoryStore()
+
+	image := shopify.Image{ID: 5, Src: server.URL + "/bag.png?v=1"}
+	first, err := getProductImage(ctx, store, image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := getProductImage(ctx, store, image)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if downloads.Load() != 1 {
+		t.Errorf("downloads = %d, want 1 for an unchanged source", downloads.Load())
+	}
+	if second.hash != first.hash || second.data != nil {
+		t.Errorf("second image = hash %s, %d bytes, want remembered hash %s without data", second.hash, len(second.data), first.hash)
+	}
+
+	if err := second.load(ctx); err != nil || !bytes.Equal(second.data, []byte("png")) {
+		t.Errorf("load() = %v, data %q, want the image content", err, second.data)
+	}
+	if downloads.Load() != 2 {
+		t.Errorf("downloads = %d, want 2 after load", downloads.Load())
+	}
+
+	image.Src = server.URL + "/bag.png?v=2"
+	if _, err := getProductImage(ctx, store, image); err != nil {
+		t.Fatal(err)
+	}
+	if downloads.Load() != 3 {
+		t.Errorf("downloads = %d, want 3 after the source changed", downloads.Load())
+	}
+}
+
+func TestProductImageLoadDetectsChangedContent(t *testing.T) {
+	server, _ := newImageServer(t, []byte("new content"))
+
+	image := &productImage{image: shopify.Image{ID: 6, Src: server.URL}, hash: "0123456789abcdef"}
+	if err := image.load(context.Background()); err == nil {
+		t.Error("load() error = nil, want an error for content that no longer matches the hash")
+	}
+}
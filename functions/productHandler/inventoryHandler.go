@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"mokobara-middleware/shared/deadline"
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/shopify"
+)
+
+// inventorySKU returns the Magento SKU of an inventory item's variant: the
+// one it was last synced to if the mapping store knows it, otherwise the SKU
+// derived by the current strategy.
+func inventorySKU(ctx context.Context, item *shopify.InventoryItem) (string, error) {
+	store, err := getMappingStore()
+	if err != nil {
+		return "", err
+	}
+	known, err := store.Get(ctx, mapping.KindVariant, strconv.FormatInt(item.VariantID, 10))
+	if err == nil && known.MagentoSKU != "" {
+		return known.MagentoSKU, nil
+	}
+	if err != nil && !mapping.IsNotFound(err) {
+		return "", err
+	}
+
+	strategy, err := getSKUStrategy()
+	if err != nil {
+		return "", err
+	}
+	return strategy.SKU(variantIdentity{Handle: item.Handle, SKU: item.SKU, VariantID: item.VariantID})
+}
+
+// inventoryHandler pushes the current stock of one inventory item to the
+// matching Magento SKU, without re-sending the rest of the product.
+func inventoryHandler(ctx context.Context, inventoryItemID int64) *SyncReport {
+	report := &SyncReport{}
+
+	ctx, cancel := deadline.WithMargin(ctx, deadline.DefaultMargin)
+	defer cancel()
+
+	shopifyClient, err := getShopifyClient()
+	if err != nil {
+		fmt.Printf("❌ Error configuring Shopify client: %v\n", err)
+		report.failConfig(err)
+		return report
+	}
+
+	item, err := shopifyClient.GetInventoryItem(ctx, inventoryItemID)
+	if err != nil {
+		fmt.Printf("❌ Error fetching inventory item %d: %v\n", inventoryItemID, err)
+		report.fail(err)
+		return report
+	}
+	report.ProductID = item.ProductID
+
+	sku, err := inventorySKU(ctx, item)
+	if err != nil {
+		fmt.Printf("❌ Error resolving SKU for inventory item %d: %v\n", inventoryItemID, err)
+		report.fail(err)
+		return report
+	}
+	if !item.Tracked {
+		fmt.Printf("🔥 Inventory item %d is not tracked, skipping %s\n", inventoryItemID, sku)
+		report.add(sku, SyncSkipped, nil)
+		return report
+	}
+
+	client, err := getMagentoClient()
+	if err != nil {
+		fmt.Printf("❌ Error configuring Magento client: %v\n", err)
+		report.failConfig(err)
+		return report
+	}
+
+	status, err := updateStock(ctx, client, sku, item)
+	if err != nil {
+		fmt.Printf("❌ Failed to update stock for %s: %v\n", sku, err)
+	}
+	report.add(sku, status, err)
+	return report
+}
+
+// updateStock writes the item's quantity to Magento, either as the total on
+// the legacy stock item or per source when MSI is enabled. SKUs that were
+// never synced to Magento, and items stocked only at unmapped locations, are
+// skipped.
+func updateStock(ctx context.Context, client *magento.Client, sku string, item *shopify.InventoryItem) (SyncStatus, error) {
+	var err error
+	if getStockMode() == StockModeMSI {
+		items := sourceItems(sku, item)
+		if len(items) == 0 {
+			fmt.Printf("⚠️ No Shopify location of %s is mapped to an MSI source, skipping stock update\n", sku)
+			return SyncSkipped, nil
+		}
+		err = client.UpdateSourceItems(ctx, items)
+		// MSI answers a SKU it does not know with a 400 rather than a 404.
+		if magento.IsBadRequest(err) {
+			if _, getErr := client.GetProduct(ctx, sku); magento.IsNotFound(getErr) {
+				err = getErr
+			}
+		}
+	} else {
+		qty := float64(item.InventoryQuantity)
+		err = client.UpdateStockItem(ctx, sku, magento.StockItem{Qty: qty, IsInStock: qty > 0})
+	}
+
+	if magento.IsNotFound(err) {
+		fmt.Printf("🔥 Product %s not in Magento, skipping stock update\n", sku)
+		return SyncSkipped, nil
+	}
+	if err != nil {
+		return SyncFailed, err
+	}
+
+	fmt.Printf("✅ Stock updated successfully: %s\n", sku)
+	return SyncUpdated, nil
+}
+
+// sourceItems groups the item's per-location quantities by MSI source.
+// Locations missing from MAGENTO_SOURCE_MAP are left out.
+func sourceItems(sku string, item *shopify.InventoryItem) []magento.SourceItem {
+	sources := getSourceCodes()
+	quantities := map[string]float64{}
+	order := []string{}
+
+	for _, level := range item.Levels {
+		source, ok := getSourceCode(sources, level.LocationID)
+		if !ok {
+			fmt.Printf("⚠️ Shopify location %d is not in MAGENTO_SOURCE_MAP, ignoring its stock of %s\n", level.LocationID, sku)
+			continue
+		}
+		if _, seen := quantities[source]; !seen {
+			order = append(order, source)
+		}
+		quantities[source] += float64(level.Available)
+	}
+
+	items := make([]magento.SourceItem, 0, len(order))
+	for _, source := range order {
+		status := magento.SourceItemOutOfStock
+		if quantities[source] > 0 {
+			status = magento.SourceItemInStock
+		}
+		items = append(items, magento.SourceItem{
+			SKU:        sku,
+			SourceCode: source,
+			Quantity:   quantities[source],
+			Status:     status,
+		})
+	}
+	return items
+}
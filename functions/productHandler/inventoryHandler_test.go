@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/shopify"
+)
+
+func TestGetSourceCodes(t *testing.T) {
+	t.Setenv("MAGENTO_SOURCE_MAP", " 1=warehouse, 2 = store ,bad,x=y,3=")
+
+	want := map[int64]string{1: "warehouse", 2: "store", 3: ""}
+	if got := getSourceCodes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("getSourceCodes() = %v, want %v", got, want)
+	}
+}
+
+func TestSourceItems(t *testing.T) {
+	t.Setenv("MAGENTO_SOURCE_MAP", "1=warehouse,2=warehouse,3=store,4=")
+
+	tests := []struct {
+		name   string
+		levels []shopify.InventoryLevel
+		want   []magento.SourceItem
+	}{
+		{
+			name:   "locations of one source are added up",
+			levels: []shopify.InventoryLevel{{LocationID: 1, Available: 3}, {LocationID: 2, Available: 4}, {LocationID: 3, Available: 0}},
+			want: []magento.SourceItem{
+				{SKU: "bag", SourceCode: "warehouse", Quantity: 7, Status: magento.SourceItemInStock},
+				{SKU: "bag", SourceCode: "store", Quantity: 0, Status: magento.SourceItemOutOfStock},
+			},
+		},
+		{
+			name:   "unmapped locations are left out",
+			levels: []shopify.InventoryLevel{{LocationID: 3, Available: 2}, {LocationID: 9, Available: 50}, {LocationID: 4, Available: 50}},
+			want: []magento.SourceItem{
+				{SKU: "bag", SourceCode: "store", Quantity: 2, Status: magento.SourceItemInStock},
+			},
+		},
+		{
+			name:   "only unmapped locations",
+			levels: []shopify.InventoryLevel{{LocationID: 9, Available: 50}},
+			want:   []magento.SourceItem{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sourceItems("bag", &shopify.InventoryItem{Levels: tt.levels})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sourceItems() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateStockMSI(t *testing.T) {
+	t.Setenv("STOCK_SYNC_MODE", StockModeMSI)
+	t.Setenv("MAGENTO_SOURCE_MAP", "1=warehouse")
+
+	tests := []struct {
+		name         string
+		levels       []shopify.InventoryLevel
+		saveStatus   int
+		productFound bool
+		wantStatus   SyncStatus
+		wantErr      bool
+		wantCalls    int
+	}{
+		{name: "saved", levels: []shopify.InventoryLevel{{LocationID: 1, Available: 2}}, saveStatus: 200, wantStatus: SyncUpdated, wantCalls: 1},
+		{name: "no mapped location", levels: []shopify.InventoryLevel{{LocationID: 9, Available: 2}}, wantStatus: SyncSkipped, wantCalls: 0},
+		{name: "unknown SKU", levels: []shopify.InventoryLevel{{LocationID: 1, Available: 2}}, saveStatus: 400, wantStatus: SyncSkipped, wantCalls: 2},
+		{name: "rejected for an existing SKU", levels: []shopify.InventoryLevel{{LocationID: 1, Available: 2}}, saveStatus: 400, productFound: true, wantStatus: SyncFailed, wantErr: true, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			client := newTestMagento(t, func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if r.Method == http.MethodGet {
+					if !tt.productFound {
+						w.WriteHeader(http.StatusNotFound)
+						w.Write([]byte(`{"message": "not found"}`))
+						return
+					}
+					w.Write([]byte(`{"sku": "bag"}`))
+					return
+				}
+				w.WriteHeader(tt.saveStatus)
+				w.Write([]byte(`[]`))
+			})
+
+			status, err := updateStock(context.Background(), client, "bag", &shopify.InventoryItem{Levels: tt.levels})
+			if status != tt.wantStatus || (err != nil) != tt.wantErr {
+				t.Errorf("updateStock() = %s, %v, want %s, error %v", status, err, tt.wantStatus, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Magento calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -3,93 +3,299 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"sync"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+
+	"mokobara-middleware/shared/apigw"
+	"mokobara-middleware/shared/deadline"
+	"mokobara-middleware/shared/dedup"
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/shopify"
 )
 
 func HandleProductRequest(ctx context.Context, request events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
 
-	fmt.Printf("🔥 Received event: %+v\n", request)
-	fmt.Printf("🔥 Request body: %s\n", request.Body)
-
-	shopifyTopic := request.Headers["X-Shopify-Topic"]
-	fmt.Printf("🔥 X-Shopify-Topic header: %s\n", shopifyTopic)
+	fmt.Printf("🔥 Received %s %s, headers: %v\n", request.RequestContext.HTTP.Method, request.RawPath, apigw.LogHeaders(request))
 
-	var data map[string]interface{}
-	err := json.Unmarshal([]byte(request.Body), &data)
+	body, err := apigw.Body(request)
 	if err != nil {
-		fmt.Printf("❌ Error unmarshalling body: %v\n", err)
+		fmt.Printf("❌ Error reading body: %v\n", err)
 		return events.APIGatewayV2HTTPResponse{
 			StatusCode: 400,
-			Body:       fmt.Sprintf(`{"error": "Invalid JSON: %v"}`, err),
+			Body:       fmt.Sprintf(`{"error": "Invalid body: %v"}`, err),
+		}, nil
+	}
+
+	secret := getWebhookSecret()
+	if secret == "" {
+		fmt.Println("❌ SHOPIFY_WEBHOOK_SECRET environment variable not set")
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: 500,
+			Body:       `{"error": "Webhook secret not configured"}`,
 		}, nil
 	}
 
-	switch shopifyTopic {
-	case "products/create":
-		fmt.Println("🔥 Handling 'products/create' event")
-		manageProductHandler(data)
+	if !verifyShopifyWebhook(body, apigw.Header(request, "X-Shopify-Hmac-Sha256"), secret) {
+		fmt.Println("❌ Invalid webhook signature")
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: 401,
+			Body:       `{"error": "Invalid webhook signature"}`,
+		}, nil
+	}
 
-	case "products/update":
-		fmt.Println("🔥 Handling 'products/update' event")
-		manageProductHandler(data)
-	default:
+	shopifyTopic := apigw.Header(request, "X-Shopify-Topic")
+	fmt.Printf("🔥 Verified %s webhook, %d bytes\n", shopifyTopic, len(body))
+
+	if !json.Valid(body) {
+		fmt.Println("❌ Error unmarshalling body: invalid JSON")
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: 400,
+			Body:       `{"error": "Invalid JSON"}`,
+		}, nil
+	}
+
+	event, err := parseSyncEvent(shopifyTopic, body)
+	if errors.Is(err, errUnknownTopic) {
 		fmt.Println("🔥 Unknown Shopify Topic:", shopifyTopic)
+		return events.APIGatewayV2HTTPResponse{
+			StatusCode: 200,
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+			Body: `{"message": "Topic ignored"}`,
+		}, nil
 	}
+	if err != nil {
+		fmt.Printf("❌ Invalid %s payload: %v\n", shopifyTopic, err)
+		return validationErrorResponse(err), nil
+	}
+	event.WebhookID = apigw.Header(request, webhookIDHeader)
 
-	fmt.Println("✅")
-	return events.APIGatewayV2HTTPResponse{
-		StatusCode: 200,
-		Headers: map[string]string{
-			"Content-Type": "application/json",
-		},
-		Body: `{"message": "test"}`,
-	}, nil
+	fmt.Printf("🔥 Handling '%s' event\n", shopifyTopic)
+	if response, ignored := checkWebhook(ctx, request, event.resource()); ignored {
+		return response, nil
+	}
+
+	if getSyncMode() == SyncModeAsync {
+		return enqueueSyncEvent(ctx, request, event), nil
+	}
 
+	report := processSyncEvent(ctx, event)
+	fmt.Println("✅")
+	return finishWebhook(ctx, request, report), nil
 }
 
-func manageProductHandler(data map[string]interface{}) {
-	productId := data["id"].(float64)
-	productIdStr := fmt.Sprintf("%.0f", productId)
+func manageProductHandler(ctx context.Context, productID int64) *SyncReport {
+	report := &SyncReport{ProductID: productID}
+
+	// Stop outbound calls shortly before the Lambda deadline so unfinished
+	// work can still be recorded.
+	ctx, cancel := deadline.WithMargin(ctx, deadline.DefaultMargin)
+	defer cancel()
 
-	product, err := getProductWithMetafields(productIdStr)
+	shopifyClient, err := getShopifyClient()
+	if err != nil {
+		fmt.Printf("❌ Error configuring Shopify client: %v\n", err)
+		report.failConfig(err)
+		return report
+	}
+
+	product, isPublished, err := getProductWithMetafields(ctx, shopifyClient, productID)
 	if err != nil {
 		fmt.Printf("❌ Error fetching product: %v\n", err)
-		return
+		report.fail(err)
+		return report
+	}
+
+	mapping, err := getFieldMapping()
+	if err != nil {
+		fmt.Printf("❌ Error loading field mapping: %v\n", err)
+		report.failConfig(err)
+		return report
 	}
+	logUnmappedMetafields(mapping, product)
 
-	payload, err := getProductPayload(product)
+	payload, err := getProductPayload(product, mapping)
 	if err != nil {
 		fmt.Printf("❌ Error generating payload: %v\n", err)
-		return
+		report.fail(err)
+		return report
+	}
+
+	client, err := getMagentoClient()
+	if err != nil {
+		fmt.Printf("❌ Error configuring Magento client: %v\n", err)
+		report.failConfig(err)
+		return report
+	}
+
+	if err := migrateSKUs(ctx, client, product, payload); err != nil {
+		fmt.Printf("❌ Error migrating SKUs: %v\n", err)
+		report.fail(err)
+		return report
+	}
+
+	configurable := getProductMode() == ProductModeConfigurable && !product.HasOnlyDefaultVariant()
+
+	if !isPublished {
+		fmt.Println("🔥 Product is not published, unpublishing in Magento")
+		if configurable {
+			payload = append(payload, getConfigurableParentPayload(product, mapping))
+		}
+		unpublishProductHandler(ctx, client, payload, mappingRecords(product, payload), report)
+		return report
 	}
 
 	fmt.Printf("🔥 Main Payload: %+v\n", payload)
 
+	if configurable {
+		parent := getConfigurableParentPayload(product, mapping)
+		syncConfigurableProduct(ctx, client, product, parent, payload, report)
+	} else {
+		syncProducts(ctx, client, payload, mappingRecords(product, payload), report)
+	}
+
+	if getSyncImages() {
+		syncImages(ctx, client, product, imageTargets(product, payload, configurable, report), report)
+	}
+	syncStoreViews(ctx, shopifyClient, client, product, payload, configurable, report)
+	return report
+}
+
+// syncProducts creates or updates every product in payload concurrently and
+// records each outcome in report.
+func syncProducts(ctx context.Context, client *magento.Client, payload []magento.ProductRequest, records map[string]mapping.Record, report *SyncReport) {
 	var wg sync.WaitGroup
-	errChan := make(chan error, len(payload)) // Capture errors from goroutines
+	var mu sync.Mutex
 
 	for _, product := range payload {
 		wg.Add(1)
-		go func(product map[string]interface{}) {
+		go func(product magento.ProductRequest) {
 			defer wg.Done()
-			if err := manageProduct(product); err != nil {
-				errChan <- err
+			status, err := manageProduct(ctx, client, product, records[product.Product.SKU])
+			if err != nil {
+				if deadline.Exceeded(err) {
+					fmt.Printf("⏳ Deadline reached before syncing SKU %s\n", product.Product.SKU)
+				} else {
+					fmt.Printf("❌ API call failed : %v\n", err)
+				}
 			}
+
+			mu.Lock()
+			report.add(product.Product.SKU, status, err)
+			mu.Unlock()
 		}(product)
 	}
 
 	wg.Wait()
-	close(errChan)
+}
 
-	for err := range errChan {
-		fmt.Printf("❌ API call failed : %v\n", err)
+// unpublishProductHandler takes every variant SKU of an unpublished product
+// off the Magento storefront, addressing each by the SKU it was last synced
+// to when the mapping store knows it. The stored payload hashes are cleared
+// so that republishing sends the full product again.
+func unpublishProductHandler(ctx context.Context, client *magento.Client, payload []magento.ProductRequest, records map[string]mapping.Record, report *SyncReport) {
+	store, err := getMappingStore()
+	if err != nil {
+		fmt.Printf("❌ Error configuring mapping store: %v\n", err)
+		report.failConfig(err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for _, product := range payload {
+		wg.Add(1)
+		go func(sku string) {
+			defer wg.Done()
+			record := records[sku]
+			status := SyncFailed
+			target, err := storedSKU(ctx, store, record, sku)
+			if err == nil {
+				status, err = unpublishProduct(ctx, client, target)
+			} else {
+				target = sku
+				fmt.Printf("❌ Error reading mapping for %s: %v\n", sku, err)
+			}
+			if status == SyncDisabled || status == SyncHidden {
+				forgetHash(ctx, record)
+			}
+
+			mu.Lock()
+			report.add(target, status, err)
+			mu.Unlock()
+		}(product.Product.SKU)
+	}
+
+	wg.Wait()
+}
+
+// validationErrorResponse answers a malformed payload with 400 and, when
+// available, the list of invalid fields.
+func validationErrorResponse(err error) events.APIGatewayV2HTTPResponse {
+	response := map[string]interface{}{"error": err.Error()}
+
+	var verr *shopify.ValidationError
+	if errors.As(err, &verr) {
+		response["fields"] = verr.Fields
+	}
+
+	body, _ := json.Marshal(response)
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: 400,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(body),
 	}
 }
 
 func main() {
+	// Load the field mapping at cold start so a broken file fails fast.
+	if _, err := getFieldMapping(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if _, err := getShopifyClient(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if _, err := getStoreViews(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if _, err := getMappingStore(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if _, err := getSyncQueue(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if _, err := getDedupStore(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if _, err := dedup.TTLFromEnv(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if _, err := getSKUStrategy(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if _, _, err := getPreviousSKUStrategy(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	// Provisioning changes the Magento catalog schema, so it only runs when
+	// asked for, once per cold start.
+	if getProvisionAttributes() {
+		provisionShopifyIDAttribute()
+	}
+
+	if getFunctionRole() == FunctionRoleWorker {
+		lambda.Start(HandleSyncQueue)
+		return
+	}
 	lambda.Start(HandleProductRequest)
 }
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/shopify"
+)
+
+func TestUnpublishProductHandlerUsesStoredSKU(t *testing.T) {
+	ctx := context.Background()
+	store, err := getMappingStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Variant 9101 was synced under an older SKU; variant 9102 never was.
+	renamed := mapping.Record{Kind: mapping.KindVariant, Key: "9101", ShopifyID: 9101, ShopifyProductID: 91, MagentoSKU: "old-bag-black"}
+	if err := store.Put(ctx, renamed); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Delete(ctx, mapping.KindVariant, "9101") })
+
+	var mu sync.Mutex
+	var updated []string
+	client := newTestMagento(t, func(w http.ResponseWriter, r *http.Request) {
+		sku := strings.TrimPrefix(r.URL.Path, "/rest/V1/products/")
+		if r.Method == http.MethodPut {
+			mu.Lock()
+			updated = append(updated, sku)
+			mu.Unlock()
+		}
+		w.Write([]byte(`{"sku": "` + sku + `", "status": 1}`))
+	})
+
+	payload := []magento.ProductRequest{
+		{Product: magento.Product{SKU: "bag-black"}},
+		{Product: magento.Product{SKU: "bag-blue"}},
+	}
+	records := map[string]mapping.Record{
+		"bag-black": {Kind: mapping.KindVariant, Key: "9101", ShopifyID: 9101, ShopifyProductID: 91, MagentoSKU: "bag-black"},
+		"bag-blue":  {Kind: mapping.KindVariant, Key: "9102", ShopifyID: 9102, ShopifyProductID: 91, MagentoSKU: "bag-blue"},
+	}
+
+	report := &SyncReport{ProductID: 91}
+	unpublishProductHandler(ctx, client, payload, records, report)
+
+	sort.Strings(updated)
+	if want := "bag-blue,old-bag-black"; strings.Join(updated, ",") != want {
+		t.Errorf("disabled SKUs = %v, want %s", updated, want)
+	}
+	for _, v := range report.Variants {
+		if v.Status != SyncDisabled {
+			t.Errorf("SKU %s status = %s, want %s", v.SKU, v.Status, SyncDisabled)
+		}
+	}
+}
+
+func TestUnchangedProductSkipsMagento(t *testing.T) {
+	ctx := context.Background()
+	server, downloads := newImageServer(t, []byte("png"))
+
+	views := getStoreViews
+	getStoreViews = func() ([]StoreView, error) { return []StoreView{{Code: "us", Rate: 0.012}}, nil }
+	t.Cleanup(func() { getStoreViews = views })
+
+	product := shopify.Product{
+		ID:       92,
+		Title:    "Bag",
+		Variants: []shopify.Variant{{ID: 9201, Title: "Black", Price: "1000.00"}},
+		Images:   []shopify.Image{{ID: 9211, Src: server.URL + "/bag.png?v=1", Position: 1}},
+	}
+	payload := []magento.ProductRequest{{Product: magento.Product{SKU: "bag-92-black", Name: "Bag Black", Price: 1000}}}
+	records := mappingRecords(product, payload)
+	t.Cleanup(func() {
+		store, _ := getMappingStore()
+		store.Delete(ctx, mapping.KindVariant, "9201")
+		store.Delete(ctx, mapping.KindImage, "9211")
+		store.Delete(ctx, mapping.KindGallery, "bag-92-black")
+		store.Delete(ctx, mapping.KindStoreView, "us/bag-92-black")
+	})
+
+	var mu sync.Mutex
+	var requests []string
+	client := newTestMagento(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/media") && r.Method == http.MethodGet:
+			w.Write([]byte(`[]`))
+		case strings.HasSuffix(r.URL.Path, "/media"):
+			w.Write([]byte(`"1"`))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "not found"}`))
+		default:
+			w.Write([]byte(`{"id": 1, "sku": "bag-92-black"}`))
+		}
+	})
+
+	run := func() []string {
+		mu.Lock()
+		requests = nil
+		mu.Unlock()
+		report := &SyncReport{ProductID: product.ID}
+		syncProducts(ctx, client, payload, records, report)
+		syncImages(ctx, client, product, imageTargets(product, payload, false, report), report)
+		syncStoreViews(ctx, nil, client, product, payload, false, report)
+		sort.Strings(requests)
+		return requests
+	}
+
+	want := "GET /rest/V1/products/bag-92-black,GET /rest/V1/products/bag-92-black/media,POST /rest/V1/products,POST /rest/V1/products/bag-92-black/media,PUT /rest/us/V1/products/bag-92-black"
+	if got := strings.Join(run(), ","); got != want {
+		t.Fatalf("first sync requests = %s, want %s", got, want)
+	}
+	if got := run(); len(got) != 0 {
+		t.Errorf("unchanged sync requests = %v, want none", got)
+	}
+
+	// A replaced image only resyncs the gallery.
+	product.Images[0].Src = server.URL + "/bag.png?v=2"
+	want = "GET /rest/V1/products/bag-92-black/media,POST /rest/V1/products/bag-92-black/media"
+	if got := strings.Join(run(), ","); got != want {
+		t.Errorf("image change requests = %s, want %s", got, want)
+	}
+	if got := downloads.Load(); got != 2 {
+		t.Errorf("image downloads = %d, want 2", got)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/shopify"
+)
+
+// mappingRecords returns, by Magento SKU, the mapping record of every SKU a
+// product syncs to: one per variant and one for a configurable parent.
+func mappingRecords(product shopify.Product, payload []magento.ProductRequest) map[string]mapping.Record {
+	records := map[string]mapping.Record{}
+	for i, variant := range product.Variants {
+		sku := payload[i].Product.SKU
+		records[sku] = mapping.Record{
+			Kind:             mapping.KindVariant,
+			Key:              strconv.FormatInt(variant.ID, 10),
+			ShopifyID:        variant.ID,
+			ShopifyProductID: product.ID,
+			MagentoSKU:       sku,
+		}
+	}
+
+	parentSKU := configurableParentSKU(product)
+	if _, ok := records[parentSKU]; !ok {
+		records[parentSKU] = mapping.Record{
+			Kind:             mapping.KindProduct,
+			Key:              strconv.FormatInt(product.ID, 10),
+			ShopifyID:        product.ID,
+			ShopifyProductID: product.ID,
+			MagentoSKU:       parentSKU,
+		}
+	}
+	return records
+}
+
+// knownProduct returns the stored mapping for record, or nil if the object
+// was never synced or there is no record template for it.
+func knownProduct(ctx context.Context, store mapping.Store, record mapping.Record) (*mapping.Record, error) {
+	if record.Kind == "" {
+		return nil, nil
+	}
+	known, err := store.Get(ctx, record.Kind, record.Key)
+	if mapping.IsNotFound(err) {
+		return nil, nil
+	}
+	return known, err
+}
+
+// storedSKU returns the Magento SKU the object of record was last synced to,
+// or sku when it was never synced.
+func storedSKU(ctx context.Context, store mapping.Store, record mapping.Record, sku string) (string, error) {
+	known, err := knownProduct(ctx, store, record)
+	if err != nil {
+		return "", err
+	}
+	if known != nil && known.MagentoSKU != "" {
+		return known.MagentoSKU, nil
+	}
+	return sku, nil
+}
+
+// payloadHash identifies the content of a Magento payload. Encoding the same
+// struct always yields the same JSON, so equal payloads hash equally.
+func payloadHash(product magento.ProductRequest) (string, error) {
+	return contentHash(product)
+}
+
+func contentHash(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// contentRecord is the record remembering the hash of what was last synced
+// to sku besides its product payload, such as its gallery or a store view.
+func contentRecord(kind mapping.Kind, key string, productID int64, sku, hash string) mapping.Record {
+	return mapping.Record{
+		Kind:             kind,
+		Key:              key,
+		ShopifyProductID: productID,
+		MagentoSKU:       sku,
+		Hash:             hash,
+	}
+}
+
+// contentSynced reports whether the stored hash of record matches its hash,
+// i.e. the same content was already synced. Without a store nothing is.
+func contentSynced(ctx context.Context, store mapping.Store, record mapping.Record) bool {
+	if store == nil {
+		return false
+	}
+	known, err := knownProduct(ctx, store, record)
+	if err != nil {
+		fmt.Printf("⚠️ Error reading mapping %s %s: %v\n", record.Kind, record.Key, err)
+		return false
+	}
+	return known != nil && known.Hash == record.Hash
+}
+
+// rememberProduct stores which Magento product a Shopify object was synced
+// to. A failed write only costs a lookup on the next sync, so it is logged
+// rather than failing the sync.
+func rememberProduct(ctx context.Context, store mapping.Store, record mapping.Record, saved *magento.Product) {
+	if record.Kind == "" {
+		return
+	}
+	if saved != nil && saved.ID != 0 {
+		record.MagentoID = strconv.Itoa(saved.ID)
+	}
+	if err := store.Put(ctx, record); err != nil {
+		fmt.Printf("⚠️ Error storing mapping for %s: %v\n", record.MagentoSKU, err)
+	}
+}
+
+// forgetHash clears the stored payload hash of record, so the next sync sends
+// the full product even if its payload did not change.
+func forgetHash(ctx context.Context, record mapping.Record) {
+	store, err := getMappingStore()
+	if err != nil || record.Kind == "" {
+		return
+	}
+	known, err := store.Get(ctx, record.Kind, record.Key)
+	if err != nil || known.Hash == "" {
+		return
+	}
+	known.Hash = ""
+	if err := store.Put(ctx, *known); err != nil {
+		fmt.Printf("⚠️ Error clearing mapping hash for %s: %v\n", known.MagentoSKU, err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"mokobara-middleware/shared/queue"
+)
+
+// enqueueSyncEvent hands an event to the worker and answers Shopify at once
+// with a 202, completing the webhook ID since the queue now owns the event.
+// If the event cannot be queued the webhook ID is released and Shopify is
+// asked to retry.
+func enqueueSyncEvent(ctx context.Context, request events.APIGatewayV2HTTPRequest, event syncEvent) events.APIGatewayV2HTTPResponse {
+	report := &SyncReport{ProductID: event.ID, Topic: event.Topic}
+
+	err := sendSyncEvent(ctx, event)
+	if err != nil {
+		fmt.Printf("❌ Error queueing %s event for %d: %v\n", event.Topic, event.ID, err)
+		report.failConfig(err)
+		return finishWebhook(ctx, request, report)
+	}
+
+	fmt.Printf("✅ Queued %s event for %d\n", event.Topic, event.ID)
+	completeWebhook(ctx, request)
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: 202,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: `{"message": "Queued"}`,
+	}
+}
+
+func sendSyncEvent(ctx context.Context, event syncEvent) error {
+	q, err := getSyncQueue()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return q.Send(ctx, queue.Message{
+		Body: body,
+		// Events about the same resource are synced in order on FIFO queues.
+		GroupID:         event.resource(),
+		DeduplicationID: event.WebhookID,
+	})
+}
+
+// minMessageTime is the least time that must be left in the invocation to
+// start syncing a queued event.
+const minMessageTime = 60 * time.Second
+
+// HandleSyncQueue is the worker entrypoint. It syncs each queued event and
+// reports the ones that failed transiently, so only those are redelivered.
+// Malformed messages can never succeed and are dropped. Once less than
+// minMessageTime is left, the remaining messages are reported as failures
+// without being started, so a slow event cannot leave the rest of the batch
+// half synced at the deadline.
+func HandleSyncQueue(ctx context.Context, sqsEvent events.SQSEvent) (events.SQSEventResponse, error) {
+	response := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{}}
+
+	for i, message := range sqsEvent.Records {
+		if d, ok := ctx.Deadline(); ok && time.Until(d) < minMessageTime {
+			fmt.Printf("⏳ Deadline near, returning %d unprocessed messages to the queue\n", len(sqsEvent.Records)-i)
+			for _, skipped := range sqsEvent.Records[i:] {
+				response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+					ItemIdentifier: skipped.MessageId,
+				})
+			}
+			break
+		}
+
+		var event syncEvent
+		if err := json.Unmarshal([]byte(message.Body), &event); err != nil || event.ID == 0 {
+			fmt.Printf("❌ Dropping malformed message %s: %s\n", message.MessageId, message.Body)
+			continue
+		}
+
+		fmt.Printf("🔥 Processing %s event for %d (attempt %s)\n", event.Topic, event.ID, receiveCount(message))
+		report := processSyncEvent(ctx, event)
+		body, _ := json.Marshal(report)
+		fmt.Printf("🔥 Sync report: %s\n", body)
+
+		if report.Transient() {
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+				ItemIdentifier: message.MessageId,
+			})
+		}
+	}
+
+	return response, nil
+}
+
+func receiveCount(message events.SQSMessage) string {
+	if count, ok := message.Attributes["ApproximateReceiveCount"]; ok {
+		return count
+	}
+	return "?"
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestHandleSyncQueue(t *testing.T) {
+	// Unknown topics fail permanently without calling out, so the messages
+	// are processed but never retried.
+	records := []events.SQSMessage{
+		{MessageId: "1", Body: `{"topic": "shop/update", "id": 1}`},
+		{MessageId: "2", Body: `not json`},
+		{MessageId: "3", Body: `{"topic": "shop/update", "id": 3}`},
+	}
+
+	tests := []struct {
+		name     string
+		timeLeft time.Duration
+		want     []string
+	}{
+		{name: "no deadline", want: []string{}},
+		{name: "enough time", timeLeft: 3 * time.Minute, want: []string{}},
+		{name: "deadline near", timeLeft: minMessageTime / 2, want: []string{"1", "2", "3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeLeft > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeLeft)
+				defer cancel()
+			}
+
+			response, err := HandleSyncQueue(ctx, events.SQSEvent{Records: records})
+			if err != nil {
+				t.Fatalf("HandleSyncQueue() error = %v", err)
+			}
+
+			got := []string{}
+			for _, failure := range response.BatchItemFailures {
+				got = append(got, failure.ItemIdentifier)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("batch failures = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("batch failures = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/shopify"
+)
+
+// SKU strategies selected by SKU_STRATEGY and SKU_PREVIOUS_STRATEGY.
+const (
+	SKUStrategyRaw       = "raw"
+	SKUStrategyHandleSKU = "handle_sku"
+	SKUStrategyVariantID = "variant_id"
+)
+
+// variantIdentity is what a Magento SKU can be derived from.
+type variantIdentity struct {
+	Handle    string
+	SKU       string
+	VariantID int64
+}
+
+// SKUStrategy derives the Magento SKU of a Shopify variant. Strategies must be
+// deterministic so every sync of a variant addresses the same Magento product.
+type SKUStrategy interface {
+	Name() string
+	SKU(variant variantIdentity) (string, error)
+}
+
+// rawSKUStrategy uses the Shopify SKU unchanged. Variants without a SKU
+// cannot be synced.
+type rawSKUStrategy struct{}
+
+func (rawSKUStrategy) Name() string { return SKUStrategyRaw }
+
+func (rawSKUStrategy) SKU(variant variantIdentity) (string, error) {
+	sku := strings.TrimSpace(variant.SKU)
+	if sku == "" {
+		return "", fmt.Errorf("variant %d has no SKU", variant.VariantID)
+	}
+	return limitSKU(sku), nil
+}
+
+// handleSKUStrategy prefixes the Shopify SKU with the product handle. Variants
+// without a SKU use their variant ID instead, so they do not collapse into
+// "handle-". Renaming the handle changes every SKU of the product.
+type handleSKUStrategy struct{}
+
+func (handleSKUStrategy) Name() string { return SKUStrategyHandleSKU }
+
+func (handleSKUStrategy) SKU(variant variantIdentity) (string, error) {
+	sku := strings.TrimSpace(variant.SKU)
+	if sku == "" {
+		sku = strconv.FormatInt(variant.VariantID, 10)
+	}
+	return limitSKU(fmt.Sprintf("%s-%s", variant.Handle, sku)), nil
+}
+
+// variantIDStrategy uses the Shopify variant ID, which survives handle and
+// SKU changes.
+type variantIDStrategy struct{}
+
+func (variantIDStrategy) Name() string { return SKUStrategyVariantID }
+
+func (variantIDStrategy) SKU(variant variantIdentity) (string, error) {
+	if variant.VariantID == 0 {
+		return "", fmt.Errorf("variant has no ID")
+	}
+	return fmt.Sprintf("shopify-%d", variant.VariantID), nil
+}
+
+// newSKUStrategy returns the strategy with the given name.
+func newSKUStrategy(name string) (SKUStrategy, error) {
+	switch name {
+	case SKUStrategyRaw:
+		return rawSKUStrategy{}, nil
+	case SKUStrategyHandleSKU:
+		return handleSKUStrategy{}, nil
+	case SKUStrategyVariantID:
+		return variantIDStrategy{}, nil
+	}
+	return nil, fmt.Errorf("unknown SKU strategy: %q", name)
+}
+
+// limitSKU shortens SKUs longer than Magento allows. The tail is replaced by
+// a hash of the full SKU so distinct long SKUs stay distinct.
+func limitSKU(sku string) string {
+	if len(sku) <= magento.MaxSKULength {
+		return sku
+	}
+	sum := sha256.Sum256([]byte(sku))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8]
+	// Cut at a rune boundary so a multibyte character is not split.
+	cut := magento.MaxSKULength - len(suffix)
+	for cut > 0 && !utf8.RuneStart(sku[cut]) {
+		cut--
+	}
+	return sku[:cut] + suffix
+}
+
+// variantSKUs derives the Magento SKU of every variant of a product, in
+// variant order, and rejects variants that would share a SKU.
+func variantSKUs(strategy SKUStrategy, product shopify.Product) ([]string, error) {
+	skus := make([]string, len(product.Variants))
+	seen := map[string]int64{}
+
+	for i, variant := range product.Variants {
+		sku, err := strategy.SKU(variantIdentity{Handle: product.Handle, SKU: variant.SKU, VariantID: variant.ID})
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[sku]; ok {
+			return nil, fmt.Errorf("variants %d and %d both map to SKU %q", other, variant.ID, sku)
+		}
+		seen[sku] = variant.ID
+		skus[i] = sku
+	}
+	return skus, nil
+}
+
+// checkSKUOwner guards against a SKU collision with another Shopify product:
+// an existing Magento product is only updated if it is untagged or tagged with
+// the same Shopify product ID.
+func checkSKUOwner(existing *magento.Product, product magento.Product) error {
+	attribute := getShopifyIDAttribute()
+	want, _ := product.CustomAttribute(attribute)
+	got, ok := existing.CustomAttribute(attribute)
+	if !ok || got == nil || got == "" || want == nil {
+		return nil
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		return fmt.Errorf("SKU %s belongs to Shopify product %v", product.SKU, got)
+	}
+	return nil
+}
+
+// migrateSKUs renames the Magento products created under the previous SKU
+// strategy, so switching strategies keeps each product's history instead of
+// creating duplicates. It only runs while SKU_PREVIOUS_STRATEGY is set, and
+// skips without a Magento lookup the variants the mapping store already knows
+// under their new SKU, including the ones it migrated. Variants already
+// migrated or never synced are left alone.
+func migrateSKUs(ctx context.Context, client *magento.Client, product shopify.Product, payload []magento.ProductRequest) error {
+	previous, ok, err := getPreviousSKUStrategy()
+	if err != nil || !ok {
+		return err
+	}
+
+	store, err := getMappingStore()
+	if err != nil {
+		fmt.Printf("⚠️ Mapping store unavailable, checking every SKU for migration: %v\n", err)
+	}
+	records := mappingRecords(product, payload)
+
+	for i, variant := range product.Variants {
+		sku := payload[i].Product.SKU
+		oldSKU, err := previous.SKU(variantIdentity{Handle: product.Handle, SKU: variant.SKU, VariantID: variant.ID})
+		if err != nil || oldSKU == sku {
+			continue
+		}
+
+		record := records[sku]
+		if store != nil {
+			known, err := knownProduct(ctx, store, record)
+			if err != nil {
+				fmt.Printf("⚠️ Error reading mapping for %s: %v\n", sku, err)
+			}
+			if known != nil && known.MagentoSKU == sku {
+				continue
+			}
+		}
+
+		existing, err := client.GetProduct(ctx, oldSKU)
+		if magento.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = client.GetProduct(ctx, sku)
+		if err == nil {
+			fmt.Printf("⚠️ Both %s and %s exist in Magento, not migrating\n", oldSKU, sku)
+			continue
+		}
+		if !magento.IsNotFound(err) {
+			return err
+		}
+
+		renamed, err := client.RenameProduct(ctx, existing.ID, oldSKU, sku)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🔁 Renamed SKU %s to %s\n", oldSKU, sku)
+		if store != nil {
+			rememberProduct(ctx, store, record, renamed)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/shopify"
+)
+
+func TestSKUStrategies(t *testing.T) {
+	longSKU := strings.Repeat("x", magento.MaxSKULength)
+
+	tests := []struct {
+		strategy string
+		variant  variantIdentity
+		want     string
+		wantErr  bool
+	}{
+		{strategy: SKUStrategyRaw, variant: variantIdentity{Handle: "bag", SKU: " BAG-01 ", VariantID: 11}, want: "BAG-01"},
+		{strategy: SKUStrategyRaw, variant: variantIdentity{Handle: "bag", VariantID: 11}, wantErr: true},
+		{strategy: SKUStrategyHandleSKU, variant: variantIdentity{Handle: "bag", SKU: "BAG-01", VariantID: 11}, want: "bag-BAG-01"},
+		{strategy: SKUStrategyHandleSKU, variant: variantIdentity{Handle: "bag", VariantID: 11}, want: "bag-11"},
+		{strategy: SKUStrategyVariantID, variant: variantIdentity{Handle: "bag", SKU: "BAG-01", VariantID: 11}, want: "shopify-11"},
+		{strategy: SKUStrategyVariantID, variant: variantIdentity{Handle: "bag", SKU: "BAG-01"}, wantErr: true},
+		{strategy: SKUStrategyHandleSKU, variant: variantIdentity{Handle: "bag", SKU: longSKU, VariantID: 11}, want: limitSKU("bag-" + longSKU)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy+"/"+tt.want, func(t *testing.T) {
+			strategy, err := newSKUStrategy(tt.strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := strategy.SKU(tt.variant)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SKU() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SKU() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := newSKUStrategy("sku"); err == nil {
+		t.Error("newSKUStrategy(\"sku\") error = nil, want unknown strategy")
+	}
+}
+
+func TestLimitSKU(t *testing.T) {
+	short := strings.Repeat("a", magento.MaxSKULength)
+	if got := limitSKU(short); got != short {
+		t.Errorf("limitSKU() changed a SKU within the limit: %q", got)
+	}
+
+	a := limitSKU(short + "-black")
+	b := limitSKU(short + "-blue")
+	if len(a) != magento.MaxSKULength || len(b) != magento.MaxSKULength {
+		t.Errorf("limitSKU() lengths = %d, %d, want %d", len(a), len(b), magento.MaxSKULength)
+	}
+	if a == b {
+		t.Errorf("limitSKU() mapped distinct SKUs to %q", a)
+	}
+	if a != limitSKU(short+"-black") {
+		t.Error("limitSKU() is not deterministic")
+	}
+
+	// "é" is two bytes; the cut at byte 55 would fall inside one.
+	multibyte := limitSKU(strings.Repeat("a", 54) + strings.Repeat("é", 10))
+	if !utf8.ValidString(multibyte) || len(multibyte) > magento.MaxSKULength {
+		t.Errorf("limitSKU() = %q (%d bytes), want valid UTF-8 within %d bytes", multibyte, len(multibyte), magento.MaxSKULength)
+	}
+	if want := strings.Repeat("a", 54) + "-"; !strings.HasPrefix(multibyte, want) {
+		t.Errorf("limitSKU() = %q, want it cut before the first é", multibyte)
+	}
+}
+
+func TestVariantSKUs(t *testing.T) {
+	product := shopify.Product{
+		Handle: "bag",
+		Variants: []shopify.Variant{
+			{ID: 11, SKU: "BAG-01"},
+			{ID: 12, SKU: "BAG-02"},
+		},
+	}
+
+	skus, err := variantSKUs(handleSKUStrategy{}, product)
+	if err != nil || strings.Join(skus, ",") != "bag-BAG-01,bag-BAG-02" {
+		t.Errorf("variantSKUs() = %v, %v, want bag-BAG-01 and bag-BAG-02", skus, err)
+	}
+
+	product.Variants[1].SKU = "BAG-01"
+	if _, err := variantSKUs(rawSKUStrategy{}, product); err == nil {
+		t.Error("variantSKUs() error = nil, want an error for variants sharing a SKU")
+	}
+}
+
+func TestCheckSKUOwner(t *testing.T) {
+	attribute := getShopifyIDAttribute()
+	tagged := func(id string) *magento.Product {
+		product := &magento.Product{SKU: "bag-BAG-01"}
+		if id != "" {
+			product.SetCustomAttribute(attribute, id)
+		}
+		return product
+	}
+
+	tests := []struct {
+		name     string
+		existing *magento.Product
+		wantErr  bool
+	}{
+		{name: "untagged", existing: tagged("")},
+		{name: "same product", existing: tagged("1")},
+		{name: "other product", existing: tagged("2"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSKUOwner(tt.existing, *tagged("1"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkSKUOwner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigrateSKUs(t *testing.T) {
+	t.Setenv("SKU_PREVIOUS_STRATEGY", SKUStrategyHandleSKU)
+	ctx := context.Background()
+	store, err := getMappingStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product := shopify.Product{
+		Handle: "bag",
+		Variants: []shopify.Variant{
+			{ID: 9311, SKU: "BAG-01"},
+			{ID: 9312, SKU: "BAG-02"},
+			{ID: 9313, SKU: "BAG-03"},
+			{ID: 9314, SKU: "BAG-04"},
+		},
+	}
+	payload := []magento.ProductRequest{
+		{Product: magento.Product{SKU: "shopify-9311"}},
+		{Product: magento.Product{SKU: "shopify-9312"}},
+		{Product: magento.Product{SKU: "shopify-9313"}},
+		{Product: magento.Product{SKU: "shopify-9314"}},
+	}
+
+	// Variant 9314 is known under its new SKU, so Magento is not asked.
+	if err := store.Put(ctx, mapping.Record{Kind: mapping.KindVariant, Key: "9314", ShopifyID: 9314, MagentoSKU: "shopify-9314"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		for _, variant := range product.Variants {
+			store.Delete(ctx, mapping.KindVariant, strconv.FormatInt(variant.ID, 10))
+		}
+	})
+
+	// bag-BAG-01 still has its old SKU, variant 9312 was already migrated and
+	// variant 9313 was never synced.
+	existing := map[string]string{
+		"bag-BAG-01":   `{"id": 101, "sku": "bag-BAG-01"}`,
+		"shopify-9312": `{"id": 102, "sku": "shopify-9312"}`,
+		"bag-BAG-04":   `{"id": 104, "sku": "bag-BAG-04"}`,
+	}
+
+	var renamed, looked []string
+	client := newTestMagento(t, func(w http.ResponseWriter, r *http.Request) {
+		sku := strings.TrimPrefix(r.URL.Path, "/rest/V1/products/")
+		if r.Method == http.MethodPut {
+			renamed = append(renamed, sku)
+			w.Write([]byte(`{"id": 101, "sku": "shopify-9311"}`))
+			return
+		}
+		looked = append(looked, sku)
+		body, ok := existing[sku]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "not found"}`))
+			return
+		}
+		w.Write([]byte(body))
+	})
+
+	if err := migrateSKUs(ctx, client, product, payload); err != nil {
+		t.Fatalf("migrateSKUs() error = %v", err)
+	}
+	if strings.Join(renamed, ",") != "bag-BAG-01" {
+		t.Errorf("renamed %v, want only bag-BAG-01", renamed)
+	}
+	if slices.Contains(looked, "bag-BAG-04") {
+		t.Errorf("looked up %v, want no lookup for the mapped variant 9314", looked)
+	}
+
+	// The migration is recorded, so the next sync does not look it up again.
+	looked = nil
+	if err := migrateSKUs(ctx, client, product, payload); err != nil {
+		t.Fatalf("migrateSKUs() error = %v", err)
+	}
+	if slices.Contains(looked, "bag-BAG-01") {
+		t.Errorf("looked up %v on the second run, want no lookup for the migrated variant 9311", looked)
+	}
+}
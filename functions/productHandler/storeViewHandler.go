@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/shopify"
+)
+
+// getStoreViews returns the store views loaded at cold start.
+var getStoreViews = sync.OnceValues(loadStoreViews)
+
+// StoreView is a Magento store view that gets its own prices, name and
+// description. Prices come from a fixed exchange Rate applied to the Shopify
+// price or, without a rate, from Shopify's presentment prices for Country.
+// Names and descriptions are the Shopify translations for Locale.
+//
+// Magento only keeps per-store prices when the catalog price scope is set to
+// website.
+type StoreView struct {
+	Code    string  `json:"code"`
+	Country string  `json:"country,omitempty"`
+	Rate    float64 `json:"rate,omitempty"`
+	Locale  string  `json:"locale,omitempty"`
+}
+
+// loadStoreViews reads MAGENTO_STORE_VIEWS, a JSON list such as
+// [{"code": "ae", "country": "AE", "locale": "ar"}, {"code": "us", "rate": 0.012}].
+func loadStoreViews() ([]StoreView, error) {
+	raw := os.Getenv("MAGENTO_STORE_VIEWS")
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var views []StoreView
+	if err := json.Unmarshal([]byte(raw), &views); err != nil {
+		return nil, fmt.Errorf("failed to parse MAGENTO_STORE_VIEWS: %w", err)
+	}
+
+	problems := []string{}
+	for i, view := range views {
+		if view.Code == "" {
+			problems = append(problems, fmt.Sprintf("store view %d: code is required", i))
+		}
+		if view.Rate == 0 && view.Country == "" && view.Locale == "" {
+			problems = append(problems, fmt.Sprintf("store view %q: needs a rate, country or locale", view.Code))
+		}
+		if view.Rate < 0 {
+			problems = append(problems, fmt.Sprintf("store view %q: rate must be positive", view.Code))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid MAGENTO_STORE_VIEWS: %s", strings.Join(problems, "; "))
+	}
+	return views, nil
+}
+
+// storeViewContent is the Shopify data one store view is built from.
+type storeViewContent struct {
+	prices       map[int64]shopify.VariantPrice
+	translations map[string]string
+}
+
+// syncStoreViews writes the store-specific prices, names and descriptions of
+// every SKU created or updated in this run to each configured store view.
+// Unchanged SKUs are left alone, and so is a store view whose update matches
+// the one last sent, unless the SKU was just created.
+func syncStoreViews(ctx context.Context, shopifyClient *shopify.Client, client *magento.Client, product shopify.Product, payload []magento.ProductRequest, configurable bool, report *SyncReport) {
+	views, err := getStoreViews()
+	if err != nil {
+		fmt.Printf("❌ Error loading store views: %v\n", err)
+		report.failConfig(err)
+		return
+	}
+
+	changed := report.skusWith(SyncCreated, SyncUpdated)
+	if len(views) == 0 || len(changed) == 0 {
+		return
+	}
+	created := report.skusWith(SyncCreated)
+
+	store, err := getMappingStore()
+	if err != nil {
+		fmt.Printf("⚠️ Mapping store unavailable, updating every store view: %v\n", err)
+	}
+
+	type storeUpdate struct {
+		client  *magento.Client
+		store   string
+		request magento.ProductRequest
+		record  mapping.Record
+	}
+	updates := []storeUpdate{}
+	add := func(scoped *magento.Client, view StoreView, request magento.ProductRequest) {
+		if storeViewEmpty(request) {
+			return
+		}
+		sku := request.Product.SKU
+		hash, err := payloadHash(request)
+		if err != nil {
+			fmt.Printf("⚠️ Error hashing %s in store view %s: %v\n", sku, view.Code, err)
+		}
+		record := contentRecord(mapping.KindStoreView, view.Code+"/"+sku, product.ID, sku, hash)
+		record.SpecialPrice = specialPrice(request.Product)
+
+		var known *mapping.Record
+		if store != nil {
+			known, err = knownProduct(ctx, store, record)
+			if err != nil {
+				fmt.Printf("⚠️ Error reading mapping %s %s: %v\n", record.Kind, record.Key, err)
+			}
+		}
+		if known != nil && !created[sku] {
+			if hash != "" && known.Hash == hash {
+				return
+			}
+			request = clearEndedSale(request, known.SpecialPrice)
+		}
+		updates = append(updates, storeUpdate{scoped, view.Code, request, record})
+	}
+
+	for _, view := range views {
+		content, err := getStoreViewContent(ctx, shopifyClient, product, view)
+		if err != nil {
+			fmt.Printf("❌ Error fetching Shopify data for store view %s: %v\n", view.Code, err)
+			for sku := range changed {
+				report.storeFailed(sku, view.Code, err)
+			}
+			continue
+		}
+
+		scoped := client.WithStore(view.Code)
+		for i, variant := range product.Variants {
+			sku := payload[i].Product.SKU
+			if !changed[sku] {
+				continue
+			}
+			request, err := getStoreViewPayload(product, &variant, sku, view, content)
+			if err != nil {
+				report.storeFailed(sku, view.Code, err)
+				continue
+			}
+			add(scoped, view, request)
+		}
+		if sku := configurableParentSKU(product); configurable && changed[sku] {
+			request, _ := getStoreViewPayload(product, nil, sku, view, content)
+			add(scoped, view, request)
+		}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for _, update := range updates {
+		wg.Add(1)
+		go func(update storeUpdate) {
+			defer wg.Done()
+			if _, err := update.client.UpdateProduct(ctx, update.request); err != nil {
+				fmt.Printf("❌ Error updating %s in store view %s: %v\n", update.request.Product.SKU, update.store, err)
+				mu.Lock()
+				report.storeFailed(update.request.Product.SKU, update.store, err)
+				mu.Unlock()
+				return
+			}
+			if store != nil && update.record.Hash != "" {
+				rememberProduct(ctx, store, update.record, nil)
+			}
+		}(update)
+	}
+
+	wg.Wait()
+}
+
+// getStoreViewContent fetches the presentment prices and translations a store
+// view needs.
+func getStoreViewContent(ctx context.Context, client *shopify.Client, product shopify.Product, view StoreView) (storeViewContent, error) {
+	var content storeViewContent
+	var err error
+
+	if view.Rate == 0 && view.Country != "" {
+		content.prices, err = client.GetContextualPrices(ctx, product.ID, view.Country)
+		if err != nil {
+			return content, err
+		}
+	}
+	if view.Locale != "" {
+		content.translations, err = client.GetProductTranslations(ctx, product.ID, view.Locale)
+		if err != nil {
+			return content, err
+		}
+	}
+	return content, nil
+}
+
+// getStoreViewPayload builds the store-scoped update of one SKU: its
+// translated name and description and, for variants, its price. variant is
+// nil for a configurable parent.
+func getStoreViewPayload(product shopify.Product, variant *shopify.Variant, sku string, view StoreView, content storeViewContent) (magento.ProductRequest, error) {
+	storeProduct := magento.Product{SKU: sku}
+
+	if title, ok := content.translations["title"]; ok && title != "" {
+		storeProduct.Name = title
+		if variant != nil {
+			storeProduct.Name = fmt.Sprintf("%s %s", title, variant.Title)
+		}
+	}
+	if description, ok := content.translations["body_html"]; ok && description != "" {
+		storeProduct.SetCustomAttribute("description", description)
+	}
+
+	if variant == nil {
+		return magento.ProductRequest{Product: storeProduct}, nil
+	}
+
+	price, specialPrice, err := storeViewPrices(*variant, view, content)
+	if err != nil {
+		return magento.ProductRequest{}, err
+	}
+	if price > 0 {
+		storeProduct.Price = price
+		if specialPrice != "" {
+			storeProduct.SetCustomAttribute("special_price", specialPrice)
+		}
+	}
+	return magento.ProductRequest{Product: storeProduct}, nil
+}
+
+// storeViewEmpty reports whether a store-scoped update carries nothing but
+// the SKU, e.g. for an untranslated configurable parent.
+func storeViewEmpty(request magento.ProductRequest) bool {
+	return request.Product.Name == "" && request.Product.Price == 0 && len(request.Product.CustomAttributes) == 0
+}
+
+// storeViewPrices returns a variant's price and special price in a store
+// view. A zero price means the store view has no price of its own.
+func storeViewPrices(variant shopify.Variant, view StoreView, content storeViewContent) (float64, string, error) {
+	if view.Rate > 0 {
+		price, specialPrice, err := variantPrices(variant)
+		if err != nil {
+			return 0, "", err
+		}
+		if specialPrice != "" {
+			sale, err := strconv.ParseFloat(specialPrice, 64)
+			if err != nil {
+				return 0, "", err
+			}
+			specialPrice = strconv.FormatFloat(roundPrice(sale*view.Rate), 'f', 2, 64)
+		}
+		return roundPrice(price * view.Rate), specialPrice, nil
+	}
+
+	presentment, ok := content.prices[variant.ID]
+	if !ok {
+		return 0, "", nil
+	}
+	localized := variant
+	localized.Price = presentment.Price
+	localized.CompareAtPrice = presentment.CompareAtPrice
+	return variantPrices(localized)
+}
+
+func roundPrice(price float64) float64 {
+	return math.Round(price*100) / 100
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"mokobara-middleware/shared/magento"
+	"mokobara-middleware/shared/mapping"
+	"mokobara-middleware/shared/shopify"
+)
+
+func TestLoadStoreViews(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    int
+		wantErr string
+	}{
+		{name: "unset", raw: "", want: 0},
+		{name: "valid", raw: `[{"code": "ae", "country": "AE", "locale": "ar"}, {"code": "us", "rate": 0.012}]`, want: 2},
+		{name: "invalid json", raw: `[{"code": }]`, wantErr: "failed to parse"},
+		{name: "missing code", raw: `[{"rate": 0.012}]`, wantErr: "code is required"},
+		{name: "nothing to sync", raw: `[{"code": "us"}]`, wantErr: "needs a rate, country or locale"},
+		{name: "negative rate", raw: `[{"code": "us", "rate": -1}]`, wantErr: "rate must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MAGENTO_STORE_VIEWS", tt.raw)
+			views, err := loadStoreViews()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("loadStoreViews() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || len(views) != tt.want {
+				t.Errorf("loadStoreViews() = %+v, %v, want %d views", views, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreViewPrices(t *testing.T) {
+	content := storeViewContent{prices: map[int64]shopify.VariantPrice{
+		11: {Price: "10.00", CompareAtPrice: "15.00", CurrencyCode: "USD"},
+		12: {Price: "12.00", CurrencyCode: "USD"},
+	}}
+
+	tests := []struct {
+		name             string
+		variant          shopify.Variant
+		view             StoreView
+		wantPrice        float64
+		wantSpecialPrice string
+		wantErr          bool
+	}{
+		{name: "rate", variant: shopify.Variant{ID: 13, Price: "1000.00"}, view: StoreView{Code: "us", Rate: 0.012}, wantPrice: 12},
+		{name: "rate is rounded to cents", variant: shopify.Variant{ID: 13, Price: "999.00"}, view: StoreView{Code: "us", Rate: 0.0123}, wantPrice: 12.29},
+		{name: "rate with a sale", variant: shopify.Variant{ID: 13, Price: "800.00", CompareAtPrice: "1000.00"}, view: StoreView{Code: "us", Rate: 0.012}, wantPrice: 12, wantSpecialPrice: "9.60"},
+		{name: "rate with an invalid price", variant: shopify.Variant{ID: 13, Price: "free"}, view: StoreView{Code: "us", Rate: 0.012}, wantErr: true},
+		{name: "presentment price with a sale", variant: shopify.Variant{ID: 11, Price: "800.00"}, view: StoreView{Code: "us", Country: "US"}, wantPrice: 15, wantSpecialPrice: "10.00"},
+		{name: "presentment price", variant: shopify.Variant{ID: 12, Price: "800.00", CompareAtPrice: "1000.00"}, view: StoreView{Code: "us", Country: "US"}, wantPrice: 12},
+		{name: "no presentment price", variant: shopify.Variant{ID: 14, Price: "800.00"}, view: StoreView{Code: "us", Country: "US"}, wantPrice: 0},
+		{name: "locale only", variant: shopify.Variant{ID: 11, Price: "800.00"}, view: StoreView{Code: "ar", Locale: "ar"}, wantPrice: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viewContent := content
+			if tt.view.Country == "" {
+				viewContent = storeViewContent{}
+			}
+			price, specialPrice, err := storeViewPrices(tt.variant, tt.view, viewContent)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("storeViewPrices() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if price != tt.wantPrice || specialPrice != tt.wantSpecialPrice {
+				t.Errorf("storeViewPrices() = %v, %q, want %v, %q", price, specialPrice, tt.wantPrice, tt.wantSpecialPrice)
+			}
+		})
+	}
+}
+
+func TestGetStoreViewPayload(t *testing.T) {
+	product := shopify.Product{ID: 7, Title: "Bag"}
+	variant := &shopify.Variant{ID: 11, Title: "Black", Price: "800.00", CompareAtPrice: "1000.00"}
+	translated := storeViewContent{translations: map[string]string{"title": "حقيبة", "body_html": "<p>جلد</p>"}}
+
+	tests := []struct {
+		name      string
+		variant   *shopify.Variant
+		view      StoreView
+		content   storeViewContent
+		want      magento.Product
+		wantEmpty bool
+		wantErr   bool
+	}{
+		{
+			name:    "translated variant with a rate",
+			variant: variant,
+			view:    StoreView{Code: "ae", Rate: 0.044, Locale: "ar"},
+			content: translated,
+			want: magento.Product{SKU: "bag-black", Name: "حقيبة Black", Price: 44, CustomAttributes: []magento.CustomAttribute{
+				{AttributeCode: "description", Value: "<p>جلد</p>"},
+				{AttributeCode: "special_price", Value: "35.20"},
+			}},
+		},
+		{
+			name:    "variant without a sale sends no special_price",
+			variant: &shopify.Variant{ID: 12, Title: "Blue", Price: "1000.00"},
+			view:    StoreView{Code: "us", Rate: 0.012},
+			want:    magento.Product{SKU: "bag-black", Price: 12},
+		},
+		{
+			name:    "translated parent has no price",
+			view:    StoreView{Code: "ae", Rate: 0.044, Locale: "ar"},
+			content: translated,
+			want: magento.Product{SKU: "bag-black", Name: "حقيبة", CustomAttributes: []magento.CustomAttribute{
+				{AttributeCode: "description", Value: "<p>جلد</p>"},
+			}},
+		},
+		{
+			name:      "untranslated parent is empty",
+			view:      StoreView{Code: "us", Rate: 0.012},
+			want:      magento.Product{SKU: "bag-black"},
+			wantEmpty: true,
+		},
+		{
+			name:    "empty translations are ignored",
+			variant: variant,
+			view:    StoreView{Code: "ae", Locale: "ar"},
+			content: storeViewContent{translations: map[string]string{"title": "", "body_html": ""}},
+			want:    magento.Product{SKU: "bag-black"},
+			// Without a rate or presentment price nothing is sent.
+			wantEmpty: true,
+		},
+		{
+			name:    "invalid price",
+			variant: &shopify.Variant{ID: 13, Price: "free"},
+			view:    StoreView{Code: "us", Rate: 0.012},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := getStoreViewPayload(product, tt.variant, "bag-black", tt.view, tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getStoreViewPayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := request.Product
+			if got.SKU != tt.want.SKU || got.Name != tt.want.Name || got.Price != tt.want.Price || len(got.CustomAttributes) != len(tt.want.CustomAttributes) {
+				t.Fatalf("getStoreViewPayload() = %+v, want %+v", got, tt.want)
+			}
+			for i, attr := range tt.want.CustomAttributes {
+				if got.CustomAttributes[i] != attr {
+					t.Errorf("custom attribute %d = %+v, want %+v", i, got.CustomAttributes[i], attr)
+				}
+			}
+			if storeViewEmpty(request) != tt.wantEmpty {
+				t.Errorf("storeViewEmpty() = %v, want %v", storeViewEmpty(request), tt.wantEmpty)
+			}
+		})
+	}
+}
+
+func TestSyncStoreViewsClearsEndedSale(t *testing.T) {
+	ctx := context.Background()
+	views := getStoreViews
+	getStoreViews = func() ([]StoreView, error) { return []StoreView{{Code: "us", Rate: 0.01}}, nil }
+	t.Cleanup(func() { getStoreViews = views })
+	t.Cleanup(func() {
+		if store, err := getMappingStore(); err == nil {
+			store.Delete(ctx, mapping.KindStoreView, "us/bag-95-black")
+		}
+	})
+
+	var bodies []string
+	client := newTestMagento(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, r.Method+" "+r.URL.Path+" "+string(body))
+		w.Write([]byte(`{"sku": "bag-95-black"}`))
+	})
+
+	product := shopify.Product{ID: 95, Title: "Bag", Variants: []shopify.Variant{{ID: 9501, Title: "Black", Price: "800.00", CompareAtPrice: "1000.00"}}}
+	payload := []magento.ProductRequest{{Product: magento.Product{SKU: "bag-95-black"}}}
+	run := func() []string {
+		bodies = nil
+		report := &SyncReport{ProductID: product.ID}
+		report.add("bag-95-black", SyncUpdated, nil)
+		syncStoreViews(ctx, nil, client, product, payload, false, report)
+		return bodies
+	}
+
+	if got := run(); len(got) != 1 || !strings.Contains(got[0], `"special_price","value":"8.00"`) {
+		t.Fatalf("sale requests = %v, want one update with special_price 8.00", got)
+	}
+
+	product.Variants[0].Price, product.Variants[0].CompareAtPrice = "1000.00", ""
+	got := run()
+	if len(got) != 1 || !strings.HasPrefix(got[0], "PUT /rest/us/V1/products/bag-95-black") || !strings.Contains(got[0], `"special_price","value":null`) {
+		t.Fatalf("ended sale requests = %v, want one update clearing special_price", got)
+	}
+
+	product.Variants[0].Price = "1100.00"
+	if got := run(); len(got) != 1 || strings.Contains(got[0], "special_price") {
+		t.Errorf("price change requests = %v, want one update without special_price", got)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"mokobara-middleware/shared/shopify"
+)
+
+// errUnknownTopic is returned for webhook topics this function does not sync.
+var errUnknownTopic = errors.New("unknown topic")
+
+// syncEvent is the part of a webhook needed to run its sync. It is what gets
+// queued in async mode.
+type syncEvent struct {
+	Topic     string `json:"topic"`
+	ID        int64  `json:"id"`
+	WebhookID string `json:"webhook_id,omitempty"`
+}
+
+// parseSyncEvent validates a webhook body and extracts the ID of the product
+// or inventory item it is about.
+func parseSyncEvent(topic string, body []byte) (syncEvent, error) {
+	event := syncEvent{Topic: topic}
+
+	switch topic {
+	case "products/create", "products/update", "products/delete":
+		webhookProduct, err := shopify.ParseProductWebhook(body)
+		if err != nil {
+			return event, err
+		}
+		event.ID = webhookProduct.ID
+
+	case "inventory_levels/update":
+		level, err := shopify.ParseInventoryLevelWebhook(body)
+		if err != nil {
+			return event, err
+		}
+		event.ID = level.InventoryItemID
+
+	case "inventory_items/update":
+		item, err := shopify.ParseInventoryItemWebhook(body)
+		if err != nil {
+			return event, err
+		}
+		event.ID = item.ID
+
+	default:
+		return event, errUnknownTopic
+	}
+
+	return event, nil
+}
+
+// resource identifies what the event is about, for ordering checks.
+func (e syncEvent) resource() string {
+	if e.Topic == "inventory_levels/update" || e.Topic == "inventory_items/update" {
+		return inventoryResource(e.ID)
+	}
+	return productResource(e.ID)
+}
+
+// processSyncEvent runs the sync for an event.
+func processSyncEvent(ctx context.Context, event syncEvent) *SyncReport {
+	var report *SyncReport
+
+	switch event.Topic {
+	case "products/create", "products/update":
+		report = manageProductHandler(ctx, event.ID)
+	case "products/delete":
+		report = deleteProductHandler(ctx, event.ID)
+	case "inventory_levels/update", "inventory_items/update":
+		report = inventoryHandler(ctx, event.ID)
+	default:
+		report = &SyncReport{}
+		report.fail(fmt.Errorf("%w: %s", errUnknownTopic, event.Topic))
+	}
+
+	report.Topic = event.Topic
+	return report
+}
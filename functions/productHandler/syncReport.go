@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"mokobara-middleware/shared/deadline"
+	"mokobara-middleware/shared/retry"
+)
+
+// SyncStatus is the outcome of syncing one variant to Magento.
+type SyncStatus string
+
+const (
+	SyncCreated SyncStatus = "created"
+	SyncUpdated SyncStatus = "updated"
+	SyncSkipped SyncStatus = "skipped"
+	// SyncUnchanged means the payload matched the last synced one, so no
+	// update was sent.
+	SyncUnchanged SyncStatus = "unchanged"
+	SyncFailed    SyncStatus = "failed"
+
+	SyncDisabled SyncStatus = "disabled"
+	SyncDeleted  SyncStatus = "deleted"
+	SyncHidden   SyncStatus = "hidden"
+)
+
+// VariantResult reports what happened to one Magento SKU.
+type VariantResult struct {
+	SKU    string     `json:"sku"`
+	Status SyncStatus `json:"status"`
+	Error  string     `json:"error,omitempty"`
+	// ImageError is set when the product synced but its images did not.
+	ImageError string `json:"image_error,omitempty"`
+	// StoreErrors holds, by store view code, store-scoped updates that failed.
+	StoreErrors map[string]string `json:"store_errors,omitempty"`
+
+	transient bool
+}
+
+// SyncReport is returned to Shopify as the webhook response body.
+type SyncReport struct {
+	ProductID int64           `json:"product_id,omitempty"`
+	Topic     string          `json:"topic"`
+	Error     string          `json:"error,omitempty"`
+	Variants  []VariantResult `json:"variants"`
+
+	transient bool
+}
+
+// fail records a product-level failure.
+func (r *SyncReport) fail(err error) {
+	r.Error = err.Error()
+	r.transient = retry.IsTransient(err)
+}
+
+// failConfig records a missing or invalid configuration. It is treated as
+// transient so webhooks are redelivered once the function is fixed.
+func (r *SyncReport) failConfig(err error) {
+	r.Error = err.Error()
+	r.transient = true
+}
+
+// add records the outcome of one SKU. Work cut short by the deadline is
+// reported as a transient failure so Shopify redelivers it.
+func (r *SyncReport) add(sku string, status SyncStatus, err error) {
+	result := VariantResult{SKU: sku, Status: status}
+	if err != nil {
+		result.Status = SyncFailed
+		result.Error = err.Error()
+		result.transient = retry.IsTransient(err)
+		if deadline.Exceeded(err) {
+			result.Error = "incomplete: " + result.Error
+		}
+	}
+	r.Variants = append(r.Variants, result)
+}
+
+// imageFailed records an image sync failure against an already synced SKU.
+func (r *SyncReport) imageFailed(sku string, err error) {
+	for i := range r.Variants {
+		if r.Variants[i].SKU == sku {
+			r.Variants[i].ImageError = err.Error()
+			r.Variants[i].transient = r.Variants[i].transient || retry.IsTransient(err)
+			return
+		}
+	}
+}
+
+// storeFailed records a failed store view update against an already synced SKU.
+func (r *SyncReport) storeFailed(sku, store string, err error) {
+	for i := range r.Variants {
+		if r.Variants[i].SKU == sku {
+			if r.Variants[i].StoreErrors == nil {
+				r.Variants[i].StoreErrors = map[string]string{}
+			}
+			r.Variants[i].StoreErrors[store] = err.Error()
+			r.Variants[i].transient = r.Variants[i].transient || retry.IsTransient(err)
+			return
+		}
+	}
+}
+
+// syncedSKUs returns the SKUs that exist in Magento after this run,
+// including unchanged ones.
+func (r *SyncReport) syncedSKUs() map[string]bool {
+	return r.skusWith(SyncCreated, SyncUpdated, SyncUnchanged)
+}
+
+// skusWith returns the SKUs whose outcome is one of statuses.
+func (r *SyncReport) skusWith(statuses ...SyncStatus) map[string]bool {
+	skus := map[string]bool{}
+	for _, result := range r.Variants {
+		if slices.Contains(statuses, result.Status) {
+			skus[result.SKU] = true
+		}
+	}
+	return skus
+}
+
+// Transient reports whether any part of the sync failed in a way that a
+// redelivery of the webhook could fix.
+func (r *SyncReport) Transient() bool {
+	if r.transient {
+		return true
+	}
+	for _, v := range r.Variants {
+		if v.transient {
+			return true
+		}
+	}
+	return false
+}
+
+// response answers Shopify. Transient failures get a 503 so Shopify retries
+// the webhook; everything else, including permanent failures, is acknowledged
+// with a 200 so it is not redelivered forever.
+func (r *SyncReport) response() events.APIGatewayV2HTTPResponse {
+	statusCode := http.StatusOK
+	if r.Transient() {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	if r.Variants == nil {
+		r.Variants = []VariantResult{}
+	}
+	body, _ := json.Marshal(r)
+
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: string(body),
+	}
+}
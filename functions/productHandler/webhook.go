@@ -0,0 +1,29 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"os"
+)
+
+// verifyShopifyWebhook checks the X-Shopify-Hmac-Sha256 signature, which is the
+// base64-encoded HMAC-SHA256 of the raw body keyed with the webhook secret.
+func verifyShopifyWebhook(body []byte, signature, secret string) bool {
+	if signature == "" || secret == "" {
+		return false
+	}
+
+	expected, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+func getWebhookSecret() string {
+	return os.Getenv("SHOPIFY_WEBHOOK_SECRET")
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const testWebhookSecret = "test-secret"
+
+func sign(body, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyShopifyWebhook(t *testing.T) {
+	body := `{"id":123,"title":"Carry-on"}`
+
+	tests := []struct {
+		name      string
+		body      string
+		signature string
+		secret    string
+		want      bool
+	}{
+		{"valid signature", body, sign(body, testWebhookSecret), testWebhookSecret, true},
+		{"tampered body", `{"id":124,"title":"Carry-on"}`, sign(body, testWebhookSecret), testWebhookSecret, false},
+		{"wrong secret", body, sign(body, "other-secret"), testWebhookSecret, false},
+		{"missing signature", body, "", testWebhookSecret, false},
+		{"missing secret", body, sign(body, ""), "", false},
+		{"signature not base64", body, "%%%", testWebhookSecret, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyShopifyWebhook([]byte(tt.body), tt.signature, tt.secret); got != tt.want {
+				t.Errorf("verifyShopifyWebhook() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleProductRequestSignature(t *testing.T) {
+	t.Setenv("SHOPIFY_WEBHOOK_SECRET", testWebhookSecret)
+
+	// An unhandled topic is acknowledged right after the signature check, so
+	// these requests never reach Shopify or Magento.
+	body := `{"id":123}`
+	signature := sign(body, testWebhookSecret)
+
+	tests := []struct {
+		name    string
+		request events.APIGatewayV2HTTPRequest
+		want    int
+	}{
+		{
+			name: "valid signature",
+			request: events.APIGatewayV2HTTPRequest{
+				Headers: map[string]string{"X-Shopify-Hmac-Sha256": signature, "X-Shopify-Topic": "shop/update"},
+				Body:    body,
+			},
+			want: 200,
+		},
+		{
+			name: "tampered body",
+			request: events.APIGatewayV2HTTPRequest{
+				Headers: map[string]string{"X-Shopify-Hmac-Sha256": signature, "X-Shopify-Topic": "shop/update"},
+				Body:    `{"id":999}`,
+			},
+			want: 401,
+		},
+		{
+			name: "wrong secret",
+			request: events.APIGatewayV2HTTPRequest{
+				Headers: map[string]string{"X-Shopify-Hmac-Sha256": sign(body, "other-secret"), "X-Shopify-Topic": "shop/update"},
+				Body:    body,
+			},
+			want: 401,
+		},
+		{
+			name: "missing header",
+			request: events.APIGatewayV2HTTPRequest{
+				Headers: map[string]string{"X-Shopify-Topic": "shop/update"},
+				Body:    body,
+			},
+			want: 401,
+		},
+		{
+			name: "base64-encoded body",
+			request: events.APIGatewayV2HTTPRequest{
+				Headers:         map[string]string{"X-Shopify-Hmac-Sha256": signature, "X-Shopify-Topic": "shop/update"},
+				Body:            base64.StdEncoding.EncodeToString([]byte(body)),
+				IsBase64Encoded: true,
+			},
+			want: 200,
+		},
+		{
+			name: "lowercased header",
+			request: events.APIGatewayV2HTTPRequest{
+				Headers: map[string]string{"x-shopify-hmac-sha256": signature, "x-shopify-topic": "shop/update"},
+				Body:    body,
+			},
+			want: 200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := HandleProductRequest(context.Background(), tt.request)
+			if err != nil {
+				t.Fatalf("HandleProductRequest() error = %v", err)
+			}
+			if response.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", response.StatusCode, tt.want, response.Body)
+			}
+		})
+	}
+}
+
+func TestHandleProductRequestWithoutSecret(t *testing.T) {
+	t.Setenv("SHOPIFY_WEBHOOK_SECRET", "")
+
+	body := `{"id":123}`
+	response, err := HandleProductRequest(context.Background(), events.APIGatewayV2HTTPRequest{
+		Headers: map[string]string{"X-Shopify-Hmac-Sha256": sign(body, ""), "X-Shopify-Topic": "shop/update"},
+		Body:    body,
+	})
+	if err != nil {
+		t.Fatalf("HandleProductRequest() error = %v", err)
+	}
+	if response.StatusCode != 500 {
+		t.Errorf("status = %d, want 500", response.StatusCode)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"mokobara-middleware/shared/shopify"
+)
+
+// Magento weight units, matching the general/locale/weight_unit setting.
+const (
+	WeightUnitKgs = "kgs"
+	WeightUnitLbs = "lbs"
+)
+
+// Shopify weight units in kilograms.
+var kilogramsPerUnit = map[string]float64{
+	"kg": 1,
+	"g":  0.001,
+	"lb": 0.45359237,
+	"oz": 0.028349523125,
+}
+
+// variantWeight returns the variant's weight in the Magento store unit. It
+// prefers weight and weight_unit and falls back to grams; ok is false when
+// Shopify has no weight for the variant.
+func variantWeight(variant shopify.Variant, storeUnit string) (weight float64, ok bool) {
+	var kilograms float64
+	if perUnit, known := kilogramsPerUnit[strings.ToLower(variant.WeightUnit)]; known && variant.Weight > 0 {
+		kilograms = variant.Weight * perUnit
+	} else if variant.Grams > 0 {
+		kilograms = float64(variant.Grams) / 1000
+	} else {
+		return 0, false
+	}
+
+	if storeUnit == WeightUnitLbs {
+		return roundWeight(kilograms / kilogramsPerUnit["lb"]), true
+	}
+	return roundWeight(kilograms), true
+}
+
+func roundWeight(weight float64) float64 {
+	return math.Round(weight*10000) / 10000
+}
+
+// applyVariantWeight sets the Magento weight from the variant, keeping the
+// mapping default and warning when Shopify has none.
+func applyVariantWeight(weight *float64, product shopify.Product, variant shopify.Variant) {
+	unit := getWeightUnit()
+	value, ok := variantWeight(variant, unit)
+	if !ok {
+		fmt.Printf("⚠️ No weight for variant %d of product %d, using default %v %s\n", variant.ID, product.ID, *weight, unit)
+		return
+	}
+	*weight = value
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"mokobara-middleware/shared/shopify"
+)
+
+func TestVariantWeight(t *testing.T) {
+	tests := []struct {
+		name      string
+		variant   shopify.Variant
+		storeUnit string
+		want      float64
+		wantOK    bool
+	}{
+		{name: "kg to kgs", variant: shopify.Variant{Weight: 1.2, WeightUnit: "kg"}, storeUnit: WeightUnitKgs, want: 1.2, wantOK: true},
+		{name: "g to kgs", variant: shopify.Variant{Weight: 850, WeightUnit: "g"}, storeUnit: WeightUnitKgs, want: 0.85, wantOK: true},
+		{name: "lb to kgs", variant: shopify.Variant{Weight: 2, WeightUnit: "lb"}, storeUnit: WeightUnitKgs, want: 0.9072, wantOK: true},
+		{name: "oz to kgs", variant: shopify.Variant{Weight: 16, WeightUnit: "oz"}, storeUnit: WeightUnitKgs, want: 0.4536, wantOK: true},
+		{name: "kg to lbs", variant: shopify.Variant{Weight: 1, WeightUnit: "kg"}, storeUnit: WeightUnitLbs, want: 2.2046, wantOK: true},
+		{name: "g to lbs", variant: shopify.Variant{Weight: 453.59237, WeightUnit: "g"}, storeUnit: WeightUnitLbs, want: 1, wantOK: true},
+		{name: "lb to lbs", variant: shopify.Variant{Weight: 3.5, WeightUnit: "lb"}, storeUnit: WeightUnitLbs, want: 3.5, wantOK: true},
+		{name: "oz to lbs", variant: shopify.Variant{Weight: 8, WeightUnit: "oz"}, storeUnit: WeightUnitLbs, want: 0.5, wantOK: true},
+		{name: "unit is case-insensitive", variant: shopify.Variant{Weight: 500, WeightUnit: "G"}, storeUnit: WeightUnitKgs, want: 0.5, wantOK: true},
+		{name: "grams fallback for an unknown unit", variant: shopify.Variant{Weight: 5, WeightUnit: "stone", Grams: 1250}, storeUnit: WeightUnitKgs, want: 1.25, wantOK: true},
+		{name: "grams fallback for a zero weight", variant: shopify.Variant{WeightUnit: "kg", Grams: 300}, storeUnit: WeightUnitKgs, want: 0.3, wantOK: true},
+		{name: "no weight", variant: shopify.Variant{WeightUnit: "kg"}, storeUnit: WeightUnitKgs, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := variantWeight(tt.variant, tt.storeUnit)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("variantWeight() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestApplyVariantWeight(t *testing.T) {
+	t.Setenv("MAGENTO_WEIGHT_UNIT", WeightUnitLbs)
+
+	weight := 1.0
+	applyVariantWeight(&weight, shopify.Product{ID: 7}, shopify.Variant{Weight: 16, WeightUnit: "oz"})
+	if weight != 1 {
+		t.Errorf("weight = %v, want 1 lbs", weight)
+	}
+
+	weight = 2.5
+	applyVariantWeight(&weight, shopify.Product{ID: 7}, shopify.Variant{ID: 11})
+	if weight != 2.5 {
+		t.Errorf("weight = %v, want the 2.5 default kept", weight)
+	}
+}
@@ -0,0 +1,61 @@
+// Package apigw holds helpers for API Gateway HTTP API requests.
+package apigw
+
+import (
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// redactedHeaders carry credentials and must never be logged, along with
+// every X-Magento-* header.
+var redactedHeaders = []string{"authorization", "x-shopify-hmac-sha256"}
+
+// Header looks up a request header. API Gateway HTTP APIs lowercase header
+// names, so fall back to a case-insensitive match.
+func Header(request events.APIGatewayV2HTTPRequest, name string) string {
+	if value, ok := request.Headers[name]; ok {
+		return value
+	}
+	for key, value := range request.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// Body returns the raw request body, decoding it if API Gateway delivered it
+// base64-encoded.
+func Body(request events.APIGatewayV2HTTPRequest) ([]byte, error) {
+	if !request.IsBase64Encoded {
+		return []byte(request.Body), nil
+	}
+
+	body, err := base64.StdEncoding.DecodeString(request.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding base64 body: %w", err)
+	}
+	return body, nil
+}
+
+// LogHeaders returns the request headers with credentials and signatures
+// replaced, so they can be written to the logs.
+func LogHeaders(request events.APIGatewayV2HTTPRequest) map[string]string {
+	headers := make(map[string]string, len(request.Headers))
+	for key, value := range request.Headers {
+		if isRedacted(key) {
+			value = "[REDACTED]"
+		}
+		headers[key] = value
+	}
+	return headers
+}
+
+func isRedacted(name string) bool {
+	lower := strings.ToLower(name)
+	return slices.Contains(redactedHeaders, lower) || strings.HasPrefix(lower, "x-magento-")
+}
@@ -0,0 +1,77 @@
+package apigw
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestHeader(t *testing.T) {
+	request := events.APIGatewayV2HTTPRequest{Headers: map[string]string{
+		"x-shopify-topic": "products/update",
+		"Authorization":   "Bearer token",
+	}}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"X-Shopify-Topic", "products/update"},
+		{"x-shopify-topic", "products/update"},
+		{"authorization", "Bearer token"},
+		{"X-Missing", ""},
+	}
+	for _, tt := range tests {
+		if got := Header(request, tt.name); got != tt.want {
+			t.Errorf("Header(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		request events.APIGatewayV2HTTPRequest
+		want    string
+		wantErr bool
+	}{
+		{"plain", events.APIGatewayV2HTTPRequest{Body: `{"id":1}`}, `{"id":1}`, false},
+		{"base64", events.APIGatewayV2HTTPRequest{Body: base64.StdEncoding.EncodeToString([]byte(`{"id":1}`)), IsBase64Encoded: true}, `{"id":1}`, false},
+		{"bad base64", events.APIGatewayV2HTTPRequest{Body: "not base64!", IsBase64Encoded: true}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Body(tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Body() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Body() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogHeadersRedactsCredentials(t *testing.T) {
+	request := events.APIGatewayV2HTTPRequest{Headers: map[string]string{
+		"authorization":         "Bearer secret",
+		"x-magento-signature":   "abc",
+		"x-magento-timestamp":   "123",
+		"X-Shopify-Hmac-Sha256": "sig",
+		"content-type":          "application/json",
+	}}
+
+	headers := LogHeaders(request)
+	for _, name := range []string{"authorization", "x-magento-signature", "x-magento-timestamp", "X-Shopify-Hmac-Sha256"} {
+		if headers[name] != "[REDACTED]" {
+			t.Errorf("header %s = %q, want it redacted", name, headers[name])
+		}
+	}
+	if headers["content-type"] != "application/json" {
+		t.Errorf("content-type = %q, want it kept", headers["content-type"])
+	}
+	if request.Headers["authorization"] != "Bearer secret" {
+		t.Error("LogHeaders modified the request headers")
+	}
+}
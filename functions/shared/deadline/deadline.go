@@ -0,0 +1,29 @@
+// Package deadline helps Lambda handlers stop outbound work before the
+// invocation deadline.
+package deadline
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultMargin is kept free before the Lambda deadline so a handler can
+// still record what was left unfinished and respond.
+const DefaultMargin = 5 * time.Second
+
+// WithMargin returns a context that is cancelled margin before ctx's
+// deadline. Contexts without a deadline are only made cancellable.
+func WithMargin(ctx context.Context, margin time.Duration) (context.Context, context.CancelFunc) {
+	d, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, d.Add(-margin))
+}
+
+// Exceeded reports whether err was caused by the context running out of time
+// or being cancelled, i.e. the work is incomplete rather than failed.
+func Exceeded(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
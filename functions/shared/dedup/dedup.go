@@ -0,0 +1,78 @@
+// Package dedup remembers processed events for a while, so at-least-once
+// deliveries can be acknowledged without being processed twice and events
+// arriving out of order can be recognised.
+package dedup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultTTL is how long events are remembered unless DEDUP_TTL says otherwise.
+const DefaultTTL = 24 * time.Hour
+
+// ClaimResult is the outcome of Store.Claim.
+type ClaimResult int
+
+const (
+	// Claimed means the id was free and is now leased to the caller.
+	Claimed ClaimResult = iota
+	// InProgress means another delivery holds an unexpired lease on the id.
+	InProgress
+	// Done means the id was completed and has not expired.
+	Done
+)
+
+// Store records event IDs and per-resource event times. Entries expire after
+// the TTL they were written with.
+//
+// An event ID is first claimed with a short lease while it is processed, then
+// either completed, so later deliveries are recognised as duplicates, or
+// released, so they are processed again. A lease left behind by a crashed
+// invocation expires on its own.
+type Store interface {
+	// Claim leases id for lease unless it is leased or completed already.
+	Claim(ctx context.Context, id string, lease time.Duration) (ClaimResult, error)
+	// Complete marks a claimed id as processed for ttl.
+	Complete(ctx context.Context, id string, ttl time.Duration) error
+	// Release forgets a claimed id so a redelivery is processed again.
+	Release(ctx context.Context, id string) error
+	// Advance records at as the time of the latest event for resource and
+	// reports whether it is not older than the one recorded before.
+	Advance(ctx context.Context, resource string, at time.Time, ttl time.Duration) (bool, error)
+}
+
+// NewFromEnv returns the store selected by DEDUP_STORE: "dynamodb" uses the
+// table named by DEDUP_TABLE and "memory", the default, remembers events for
+// the life of the process.
+func NewFromEnv() (Store, error) {
+	switch mode := os.Getenv("DEDUP_STORE"); mode {
+	case "", "memory":
+		return NewMemoryStore(), nil
+
+	case "dynamodb":
+		table := os.Getenv("DEDUP_TABLE")
+		if table == "" {
+			return nil, fmt.Errorf("DEDUP_TABLE environment variable not set")
+		}
+		return NewDynamoDBStore(context.Background(), table)
+
+	default:
+		return nil, fmt.Errorf("unknown DEDUP_STORE: %s", mode)
+	}
+}
+
+// TTLFromEnv returns the duration set by DEDUP_TTL, e.g. "48h", or DefaultTTL.
+func TTLFromEnv() (time.Duration, error) {
+	value := os.Getenv("DEDUP_TTL")
+	if value == "" {
+		return DefaultTTL, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid DEDUP_TTL: %q", value)
+	}
+	return ttl, nil
+}
@@ -0,0 +1,134 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBStore keeps entries in a DynamoDB table whose partition key is the
+// string attribute "pk". Entries carry their expiry in "expires_at" (epoch
+// seconds), which should be the table's TTL attribute; expired entries are
+// also ignored before DynamoDB removes them.
+type DynamoDBStore struct {
+	client *dynamodb.Client
+	table  string
+	now    func() time.Time
+}
+
+// Values of the "state" attribute of webhook items.
+const (
+	stateInProgress = "in_progress"
+	stateDone       = "done"
+)
+
+// NewDynamoDBStore returns a store for table using the default AWS
+// configuration of the environment.
+func NewDynamoDBStore(ctx context.Context, table string) (*DynamoDBStore, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("dedup: failed to load AWS config: %w", err)
+	}
+	return &DynamoDBStore{client: dynamodb.NewFromConfig(cfg), table: table, now: time.Now}, nil
+}
+
+// Claim writes an in-progress item unless an unexpired one exists, and reads
+// the state of the existing item from the failed condition.
+func (s *DynamoDBStore) Claim(ctx context.Context, id string, lease time.Duration) (ClaimResult, error) {
+	now := s.now()
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item: map[string]types.AttributeValue{
+			"pk":         &types.AttributeValueMemberS{Value: "webhook#" + id},
+			"state":      &types.AttributeValueMemberS{Value: stateInProgress},
+			"expires_at": epoch(now.Add(lease)),
+		},
+		ConditionExpression: aws.String("attribute_not_exists(pk) OR expires_at < :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": epoch(now),
+		},
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err == nil {
+		return Claimed, nil
+	}
+
+	var failed *types.ConditionalCheckFailedException
+	if !errors.As(err, &failed) {
+		return 0, fmt.Errorf("dedup: failed to claim %s: %w", id, err)
+	}
+	// Items written before leases existed have no state and count as done.
+	if state, ok := failed.Item["state"].(*types.AttributeValueMemberS); ok && state.Value == stateInProgress {
+		return InProgress, nil
+	}
+	return Done, nil
+}
+
+func (s *DynamoDBStore) Complete(ctx context.Context, id string, ttl time.Duration) error {
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item: map[string]types.AttributeValue{
+			"pk":         &types.AttributeValueMemberS{Value: "webhook#" + id},
+			"state":      &types.AttributeValueMemberS{Value: stateDone},
+			"expires_at": epoch(s.now().Add(ttl)),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("dedup: failed to complete %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) Release(ctx context.Context, id string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.table),
+		Key: map[string]types.AttributeValue{
+			"pk": &types.AttributeValueMemberS{Value: "webhook#" + id},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("dedup: failed to release %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) Advance(ctx context.Context, resource string, at time.Time, ttl time.Duration) (bool, error) {
+	now := s.now()
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item: map[string]types.AttributeValue{
+			"pk":         &types.AttributeValueMemberS{Value: "resource#" + resource},
+			"event_at":   &types.AttributeValueMemberN{Value: strconv.FormatInt(at.UnixNano(), 10)},
+			"expires_at": epoch(now.Add(ttl)),
+		},
+		ConditionExpression: aws.String("attribute_not_exists(pk) OR expires_at < :now OR event_at <= :at"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": epoch(now),
+			":at":  &types.AttributeValueMemberN{Value: strconv.FormatInt(at.UnixNano(), 10)},
+		},
+	})
+	return conditional(err, "advance "+resource)
+}
+
+// conditional turns a failed write condition into false.
+func conditional(err error, action string) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return false, nil
+	}
+	return false, fmt.Errorf("dedup: failed to %s: %w", action, err)
+}
+
+func epoch(t time.Time) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(t.Unix(), 10)}
+}
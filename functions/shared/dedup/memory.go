@@ -0,0 +1,78 @@
+package dedup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	at        time.Time
+	expiresAt time.Time
+}
+
+type memoryClaim struct {
+	done      bool
+	expiresAt time.Time
+}
+
+// MemoryStore keeps entries in memory. In Lambda it only catches redeliveries
+// that reach the same instance.
+type MemoryStore struct {
+	mu        sync.Mutex
+	claims    map[string]memoryClaim
+	resources map[string]memoryEntry
+	now       func() time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		claims:    map[string]memoryClaim{},
+		resources: map[string]memoryEntry{},
+		now:       time.Now,
+	}
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, id string, lease time.Duration) (ClaimResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if claim, ok := s.claims[id]; ok && now.Before(claim.expiresAt) {
+		if claim.done {
+			return Done, nil
+		}
+		return InProgress, nil
+	}
+	s.claims[id] = memoryClaim{expiresAt: now.Add(lease)}
+	return Claimed, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, id string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.claims[id] = memoryClaim{done: true, expiresAt: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.claims, id)
+	return nil
+}
+
+func (s *MemoryStore) Advance(ctx context.Context, resource string, at time.Time, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if entry, ok := s.resources[resource]; ok && now.Before(entry.expiresAt) && at.Before(entry.at) {
+		return false, nil
+	}
+	s.resources[resource] = memoryEntry{at: at, expiresAt: now.Add(ttl)}
+	return true, nil
+}
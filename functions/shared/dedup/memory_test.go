@@ -0,0 +1,61 @@
+package dedup
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreClaim(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	claim := func(id string, want ClaimResult) {
+		t.Helper()
+		got, err := store.Claim(ctx, id, time.Minute)
+		if err != nil || got != want {
+			t.Fatalf("Claim(%s) = %v, %v, want %v", id, got, err, want)
+		}
+	}
+
+	claim("a", Claimed)
+	claim("a", InProgress)
+
+	// A released lease can be claimed again.
+	store.Release(ctx, "a")
+	claim("a", Claimed)
+
+	// A completed id is a duplicate until its TTL runs out.
+	store.Complete(ctx, "a", time.Hour)
+	claim("a", Done)
+	now = now.Add(2 * time.Hour)
+	claim("a", Claimed)
+
+	// A lease left behind by a crashed delivery expires on its own.
+	now = now.Add(2 * time.Minute)
+	claim("a", Claimed)
+}
+
+func TestMemoryStoreAdvance(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		at   time.Time
+		want bool
+	}{
+		{at: base, want: true},
+		{at: base.Add(time.Second), want: true},
+		{at: base, want: false},
+		{at: base.Add(time.Second), want: true},
+	}
+	for i, tt := range tests {
+		got, err := store.Advance(ctx, "product:1", tt.at, time.Hour)
+		if err != nil || got != tt.want {
+			t.Errorf("Advance #%d = %v, %v, want %v", i, got, err, tt.want)
+		}
+	}
+}
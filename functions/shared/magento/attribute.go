@@ -0,0 +1,91 @@
+package magento
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Attribute is a product EAV attribute.
+type Attribute struct {
+	AttributeID          int               `json:"attribute_id,omitempty"`
+	AttributeCode        string            `json:"attribute_code"`
+	FrontendInput        string            `json:"frontend_input"`
+	DefaultFrontendLabel string            `json:"default_frontend_label,omitempty"`
+	Scope                string            `json:"scope,omitempty"`
+	Options              []AttributeOption `json:"options,omitempty"`
+}
+
+// AttributeGroup is a group of an attribute set, e.g. "General".
+type AttributeGroup struct {
+	AttributeGroupID   string `json:"attribute_group_id"`
+	AttributeGroupName string `json:"attribute_group_name"`
+	AttributeSetID     int    `json:"attribute_set_id"`
+}
+
+// AttributeOption is one selectable value of a dropdown attribute.
+type AttributeOption struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// OptionValue returns the option ID for label, matched case-insensitively.
+func (a Attribute) OptionValue(label string) (string, bool) {
+	for _, option := range a.Options {
+		if option.Value != "" && strings.EqualFold(strings.TrimSpace(option.Label), strings.TrimSpace(label)) {
+			return option.Value, true
+		}
+	}
+	return "", false
+}
+
+// GetAttribute fetches a product attribute with its options.
+func (c *Client) GetAttribute(ctx context.Context, code string) (*Attribute, error) {
+	var attribute Attribute
+	if err := c.do(ctx, http.MethodGet, "/V1/products/attributes/"+url.PathEscape(code), nil, &attribute); err != nil {
+		return nil, err
+	}
+	return &attribute, nil
+}
+
+// CreateAttribute creates a product attribute and returns it as saved.
+func (c *Client) CreateAttribute(ctx context.Context, attribute Attribute) (*Attribute, error) {
+	var created Attribute
+	body := map[string]Attribute{"attribute": attribute}
+	if err := c.do(ctx, http.MethodPost, "/V1/products/attributes", body, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetAttributeGroups returns the groups of an attribute set.
+func (c *Client) GetAttributeGroups(ctx context.Context, attributeSetID int) ([]AttributeGroup, error) {
+	criteria := SearchCriteria{Filters: []Filter{{Field: "attribute_set_id", Value: strconv.Itoa(attributeSetID)}}}
+	var response struct {
+		Items []AttributeGroup `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/V1/products/attribute-sets/groups/list?"+criteria.query(1), nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Items, nil
+}
+
+// AssignAttribute adds an attribute to a group of an attribute set, so
+// products of that set store its value. Assigning it again is harmless.
+func (c *Client) AssignAttribute(ctx context.Context, attributeSetID int, attributeGroupID, code string) error {
+	body := map[string]interface{}{
+		"attributeSetId":   attributeSetID,
+		"attributeGroupId": attributeGroupID,
+		"attributeCode":    code,
+		"sortOrder":        0,
+	}
+	return c.do(ctx, http.MethodPost, "/V1/products/attribute-sets/attributes", body, nil)
+}
+
+// AddAttributeOption adds a new option to a dropdown attribute.
+func (c *Client) AddAttributeOption(ctx context.Context, code, label string) error {
+	body := map[string]AttributeOption{"option": {Label: label}}
+	return c.do(ctx, http.MethodPost, "/V1/products/attributes/"+url.PathEscape(code)+"/options", body, nil)
+}
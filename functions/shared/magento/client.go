@@ -0,0 +1,135 @@
+// Package magento is a small client for the Magento 2 REST API.
+package magento
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"mokobara-middleware/shared/retry"
+)
+
+// Config configures a Client.
+type Config struct {
+	// BaseURL is the store URL, e.g. https://shop.example.com.
+	BaseURL string
+	// Token is the integration access token sent as a bearer token.
+	Token string
+	// HTTPClient is used for every request. Defaults to a client that retries
+	// transient failures with backoff.
+	HTTPClient *http.Client
+}
+
+// Client calls the Magento REST API.
+type Client struct {
+	baseURL    string
+	token      string
+	storeCode  string
+	httpClient *http.Client
+}
+
+// NewClient returns a Client for cfg.
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("magento: base URL is required")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("magento: token is required")
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = retry.NewClient()
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(cfg.BaseURL, "/"),
+		token:      cfg.Token,
+		httpClient: httpClient,
+	}, nil
+}
+
+// NewFromEnv returns a Client configured from BASE_URL and URL_TOKEN.
+func NewFromEnv() (*Client, error) {
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		return nil, fmt.Errorf("BASE_URL environment variable not set")
+	}
+	token := os.Getenv("URL_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("URL_TOKEN environment variable not set")
+	}
+
+	return NewClient(Config{BaseURL: baseURL, Token: token})
+}
+
+// WithStore returns a copy of the client whose calls are scoped to the store
+// view code, i.e. sent to /rest/{code}/V1 instead of /rest/V1.
+func (c *Client) WithStore(code string) *Client {
+	scoped := *c
+	scoped.storeCode = code
+	return &scoped
+}
+
+// do sends a JSON request to path (relative to /rest) and decodes a successful
+// response into out, if out is non-nil. Non-2xx responses are returned as *Error.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("magento: failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	endpoint := c.baseURL + "/rest"
+	if c.storeCode != "" {
+		endpoint += "/" + url.PathEscape(c.storeCode)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint+path, body)
+	if err != nil {
+		return fmt.Errorf("magento: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("magento: %s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("magento: failed to read response: %w", err)
+	}
+
+	log.Printf("🌐 Magento %s %s: %d\n", method, path, resp.StatusCode)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &Error{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Body:       string(respBody),
+		}
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("magento: failed to decode response from %s %s: %w", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package magento
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// recordPaths returns a client whose requests are answered with body and
+// whose raw request paths are appended to paths.
+func recordPaths(t *testing.T, body string, paths *[]string) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.RequestURI)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(Config{BaseURL: server.URL, Token: "token", HTTPClient: server.Client()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestPathSegmentsAreEscaped(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		body string
+		call func(c *Client) error
+		want string
+	}{
+		{
+			name: "product sku",
+			body: `{"sku": "a"}`,
+			call: func(c *Client) error { _, err := c.GetProduct(ctx, "bag/large blue?"); return err },
+			want: "/rest/V1/products/bag%2Flarge%20blue%3F",
+		},
+		{
+			name: "order id",
+			body: `"pending"`,
+			call: func(c *Client) error { _, err := c.GetOrderStatus(ctx, "100/../1"); return err },
+			want: "/rest/V1/orders/100%2F..%2F1/status",
+		},
+		{
+			name: "attribute code",
+			body: `{"attribute_code": "size"}`,
+			call: func(c *Client) error { _, err := c.GetAttribute(ctx, "size/x"); return err },
+			want: "/rest/V1/products/attributes/size%2Fx",
+		},
+		{
+			name: "store view",
+			body: `{"sku": "a"}`,
+			call: func(c *Client) error { _, err := c.WithStore("ae").GetProduct(ctx, "a"); return err },
+			want: "/rest/ae/V1/products/a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			client := recordPaths(t, tt.body, &paths)
+			if err := tt.call(client); err != nil {
+				t.Fatalf("call error = %v", err)
+			}
+			if len(paths) != 1 || paths[0] != tt.want {
+				t.Errorf("paths = %v, want [%s]", paths, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotFoundIsReportedAsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "not found"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient(Config{BaseURL: server.URL, Token: "token", HTTPClient: server.Client()})
+	_, err := client.GetProduct(context.Background(), "missing")
+	if !IsNotFound(err) {
+		t.Fatalf("GetProduct() error = %v, want not found", err)
+	}
+}
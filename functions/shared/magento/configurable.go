@@ -0,0 +1,36 @@
+package magento
+
+import (
+	"context"
+	"net/http"
+)
+
+// GetConfigurableOptions returns the super attributes of a configurable product.
+func (c *Client) GetConfigurableOptions(ctx context.Context, sku string) ([]ConfigurableOption, error) {
+	var options []ConfigurableOption
+	if err := c.do(ctx, http.MethodGet, "/V1/configurable-products/"+escapeSKU(sku)+"/options/all", nil, &options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// AddConfigurableOption adds a super attribute to a configurable product.
+func (c *Client) AddConfigurableOption(ctx context.Context, sku string, option ConfigurableOption) error {
+	body := map[string]ConfigurableOption{"option": option}
+	return c.do(ctx, http.MethodPost, "/V1/configurable-products/"+escapeSKU(sku)+"/options", body, nil)
+}
+
+// GetConfigurableChildren returns the simple products linked to a configurable product.
+func (c *Client) GetConfigurableChildren(ctx context.Context, sku string) ([]Product, error) {
+	var children []Product
+	if err := c.do(ctx, http.MethodGet, "/V1/configurable-products/"+escapeSKU(sku)+"/children", nil, &children); err != nil {
+		return nil, err
+	}
+	return children, nil
+}
+
+// AddConfigurableChild links a simple product to a configurable product.
+func (c *Client) AddConfigurableChild(ctx context.Context, sku, childSKU string) error {
+	body := map[string]string{"childSku": childSKU}
+	return c.do(ctx, http.MethodPost, "/V1/configurable-products/"+escapeSKU(sku)+"/child", body, nil)
+}
@@ -0,0 +1,39 @@
+package magento
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error is returned for any non-2xx response from Magento.
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("magento: %s %s returned %d: %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// Transient reports whether the request may succeed if retried later:
+// rate limiting, timeouts and server-side errors.
+func (e *Error) Transient() bool {
+	return e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode == http.StatusRequestTimeout ||
+		e.StatusCode >= 500
+}
+
+// IsNotFound reports whether err is a Magento 404 response.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsBadRequest reports whether err is a Magento 400 response.
+func IsBadRequest(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest
+}
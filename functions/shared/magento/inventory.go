@@ -0,0 +1,33 @@
+package magento
+
+import (
+	"context"
+	"net/http"
+)
+
+// Source item status values.
+const (
+	SourceItemOutOfStock = 0
+	SourceItemInStock    = 1
+)
+
+// SourceItem is the quantity of a SKU at one MSI source.
+type SourceItem struct {
+	SKU        string  `json:"sku"`
+	SourceCode string  `json:"source_code"`
+	Quantity   float64 `json:"quantity"`
+	Status     int     `json:"status"`
+}
+
+// UpdateStockItem sets the legacy (single source) stock of a product.
+func (c *Client) UpdateStockItem(ctx context.Context, sku string, stockItem StockItem) error {
+	// Magento ignores the item ID in the path and resolves the stock item by SKU.
+	body := map[string]StockItem{"stockItem": stockItem}
+	return c.do(ctx, http.MethodPut, "/V1/products/"+escapeSKU(sku)+"/stockItems/1", body, nil)
+}
+
+// UpdateSourceItems saves MSI source item quantities.
+func (c *Client) UpdateSourceItems(ctx context.Context, items []SourceItem) error {
+	body := map[string][]SourceItem{"sourceItems": items}
+	return c.do(ctx, http.MethodPost, "/V1/inventory/source-items", body, nil)
+}
@@ -0,0 +1,57 @@
+package magento
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Image roles assigned to media gallery entries.
+const (
+	ImageRoleBase      = "image"
+	ImageRoleSmall     = "small_image"
+	ImageRoleThumbnail = "thumbnail"
+)
+
+// MainImageRoles are the roles of a product's main image.
+var MainImageRoles = []string{ImageRoleBase, ImageRoleSmall, ImageRoleThumbnail}
+
+// FileName returns the base name of the stored image file.
+func (e MediaGalleryEntry) FileName() string {
+	return path.Base(e.File)
+}
+
+// GetMediaEntries returns the media gallery of a product.
+func (c *Client) GetMediaEntries(ctx context.Context, sku string) ([]MediaGalleryEntry, error) {
+	var entries []MediaGalleryEntry
+	if err := c.do(ctx, http.MethodGet, "/V1/products/"+escapeSKU(sku)+"/media", nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// AddMediaEntry uploads a gallery entry and returns its ID.
+func (c *Client) AddMediaEntry(ctx context.Context, sku string, entry MediaGalleryEntry) (int, error) {
+	// The ID comes back as a JSON string or number depending on the version.
+	var id json.RawMessage
+	body := map[string]MediaGalleryEntry{"entry": entry}
+	if err := c.do(ctx, http.MethodPost, "/V1/products/"+escapeSKU(sku)+"/media", body, &id); err != nil {
+		return 0, err
+	}
+
+	entryID, err := strconv.Atoi(strings.Trim(string(id), `"`))
+	if err != nil {
+		return 0, fmt.Errorf("magento: unexpected media entry ID %s", id)
+	}
+	return entryID, nil
+}
+
+// UpdateMediaEntry updates the label, position or roles of an existing entry.
+func (c *Client) UpdateMediaEntry(ctx context.Context, sku string, entry MediaGalleryEntry) error {
+	body := map[string]MediaGalleryEntry{"entry": entry}
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/V1/products/%s/media/%d", escapeSKU(sku), entry.ID), body, nil)
+}
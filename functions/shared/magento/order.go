@@ -0,0 +1,32 @@
+package magento
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// GetOrderStatus returns the status code of an order, e.g. "pending".
+func (c *Client) GetOrderStatus(ctx context.Context, orderID string) (string, error) {
+	var raw json.RawMessage
+	if err := c.do(ctx, http.MethodGet, "/V1/orders/"+url.PathEscape(orderID)+"/status", nil, &raw); err != nil {
+		return "", err
+	}
+
+	// The endpoint returns a bare JSON string, but some installs wrap it in an object.
+	var status string
+	if err := json.Unmarshal(raw, &status); err == nil {
+		return status, nil
+	}
+
+	var statusResponse struct {
+		Status      string `json:"status"`
+		StatusLabel string `json:"status_label"`
+	}
+	if err := json.Unmarshal(raw, &statusResponse); err != nil {
+		return "", fmt.Errorf("magento: failed to decode order status: %w", err)
+	}
+	return statusResponse.Status, nil
+}
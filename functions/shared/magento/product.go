@@ -0,0 +1,199 @@
+package magento
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Magento product status values.
+const (
+	StatusEnabled  = 1
+	StatusDisabled = 2
+)
+
+// Magento product visibility values.
+const (
+	VisibilityNotVisible = 1
+	VisibilityCatalog    = 2
+	VisibilitySearch     = 3
+	VisibilityBoth       = 4
+)
+
+// Magento product type IDs.
+const (
+	TypeSimple       = "simple"
+	TypeConfigurable = "configurable"
+)
+
+// ProductRequest is the body of POST /rest/V1/products and
+// PUT /rest/V1/products/{sku}.
+type ProductRequest struct {
+	Product     Product `json:"product"`
+	SaveOptions bool    `json:"saveOptions,omitempty"`
+}
+
+// Product is a catalog product as accepted and returned by the REST API.
+// Zero-valued fields are omitted so the same type can carry partial updates.
+type Product struct {
+	ID                  int                  `json:"id,omitempty"`
+	SKU                 string               `json:"sku"`
+	Name                string               `json:"name,omitempty"`
+	AttributeSetID      int                  `json:"attribute_set_id,omitempty"`
+	Price               float64              `json:"price,omitempty"`
+	Status              int                  `json:"status,omitempty"`
+	Visibility          int                  `json:"visibility,omitempty"`
+	TypeID              string               `json:"type_id,omitempty"`
+	Weight              float64              `json:"weight,omitempty"`
+	ExtensionAttributes *ExtensionAttributes `json:"extension_attributes,omitempty"`
+	CustomAttributes    []CustomAttribute    `json:"custom_attributes,omitempty"`
+	MediaGalleryEntries []MediaGalleryEntry  `json:"media_gallery_entries,omitempty"`
+}
+
+// ExtensionAttributes holds the product's extension_attributes.
+type ExtensionAttributes struct {
+	WebsiteIDs                 []int                `json:"website_ids,omitempty"`
+	StockItem                  *StockItem           `json:"stock_item,omitempty"`
+	ConfigurableProductOptions []ConfigurableOption `json:"configurable_product_options,omitempty"`
+	ConfigurableProductLinks   []int                `json:"configurable_product_links,omitempty"`
+}
+
+// StockItem is the legacy (single source) stock record of a product.
+type StockItem struct {
+	ItemID    int     `json:"item_id,omitempty"`
+	ProductID int     `json:"product_id,omitempty"`
+	StockID   int     `json:"stock_id,omitempty"`
+	Qty       float64 `json:"qty"`
+	IsInStock bool    `json:"is_in_stock"`
+}
+
+// CustomAttribute is an EAV attribute value. Value is usually a string
+// but can be an array for multi-value attributes such as category_ids.
+type CustomAttribute struct {
+	AttributeCode string      `json:"attribute_code"`
+	Value         interface{} `json:"value"`
+}
+
+// MediaGalleryEntry is an image attached to a product.
+type MediaGalleryEntry struct {
+	ID        int           `json:"id,omitempty"`
+	MediaType string        `json:"media_type"`
+	Label     string        `json:"label"`
+	Position  int           `json:"position"`
+	Disabled  bool          `json:"disabled"`
+	Types     []string      `json:"types"`
+	File      string        `json:"file,omitempty"`
+	Content   *ImageContent `json:"content,omitempty"`
+}
+
+// ImageContent carries the image bytes when uploading a gallery entry.
+type ImageContent struct {
+	Base64EncodedData string `json:"base64_encoded_data"`
+	Type              string `json:"type"`
+	Name              string `json:"name"`
+}
+
+// ConfigurableOption is a super attribute of a configurable product.
+type ConfigurableOption struct {
+	ID           int                       `json:"id,omitempty"`
+	AttributeID  string                    `json:"attribute_id"`
+	Label        string                    `json:"label"`
+	Position     int                       `json:"position"`
+	IsUseDefault bool                      `json:"is_use_default"`
+	Values       []ConfigurableOptionValue `json:"values"`
+}
+
+// ConfigurableOptionValue is one attribute option used by a configurable product.
+type ConfigurableOptionValue struct {
+	ValueIndex int `json:"value_index"`
+}
+
+// CustomAttribute returns the value of the named custom attribute.
+func (p Product) CustomAttribute(code string) (interface{}, bool) {
+	for _, attr := range p.CustomAttributes {
+		if attr.AttributeCode == code {
+			return attr.Value, true
+		}
+	}
+	return nil, false
+}
+
+// SetCustomAttribute adds the named custom attribute or replaces its value.
+func (p *Product) SetCustomAttribute(code string, value interface{}) {
+	for i, attr := range p.CustomAttributes {
+		if attr.AttributeCode == code {
+			p.CustomAttributes[i].Value = value
+			return
+		}
+	}
+	p.CustomAttributes = append(p.CustomAttributes, CustomAttribute{AttributeCode: code, Value: value})
+}
+
+// MaxSKULength is the longest SKU Magento accepts.
+const MaxSKULength = 64
+
+// escapeSKU escapes a SKU for use as a URL path segment, so SKUs containing
+// "/", spaces or "?" address the right product.
+func escapeSKU(sku string) string {
+	return url.PathEscape(sku)
+}
+
+// GetProduct fetches a product by SKU. A missing product is reported as an
+// *Error for which IsNotFound returns true.
+func (c *Client) GetProduct(ctx context.Context, sku string) (*Product, error) {
+	var product Product
+	if err := c.do(ctx, http.MethodGet, "/V1/products/"+escapeSKU(sku), nil, &product); err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// CreateProduct creates a product and returns it as saved by Magento.
+func (c *Client) CreateProduct(ctx context.Context, product ProductRequest) (*Product, error) {
+	var saved Product
+	if err := c.do(ctx, http.MethodPost, "/V1/products", product, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// UpdateProduct updates the product identified by product.Product.SKU.
+func (c *Client) UpdateProduct(ctx context.Context, product ProductRequest) (*Product, error) {
+	var saved Product
+	if err := c.do(ctx, http.MethodPut, "/V1/products/"+escapeSKU(product.Product.SKU), product, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// DeleteProduct removes the product with the given SKU.
+func (c *Client) DeleteProduct(ctx context.Context, sku string) error {
+	return c.do(ctx, http.MethodDelete, "/V1/products/"+escapeSKU(sku), nil, nil)
+}
+
+// RenameProduct changes the SKU of the product with the given entity ID,
+// keeping its attributes, stock and media.
+func (c *Client) RenameProduct(ctx context.Context, id int, oldSKU, newSKU string) (*Product, error) {
+	var saved Product
+	body := ProductRequest{Product: Product{ID: id, SKU: newSKU}}
+	if err := c.do(ctx, http.MethodPut, "/V1/products/"+escapeSKU(oldSKU), body, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// SetProductStatus enables or disables a product without touching its other
+// attributes.
+func (c *Client) SetProductStatus(ctx context.Context, sku string, status int) error {
+	product := ProductRequest{Product: Product{SKU: sku, Status: status}}
+	_, err := c.UpdateProduct(ctx, product)
+	return err
+}
+
+// SetProductVisibility changes where a product is shown without touching its
+// other attributes.
+func (c *Client) SetProductVisibility(ctx context.Context, sku string, visibility int) error {
+	product := ProductRequest{Product: Product{SKU: sku, Visibility: visibility}}
+	_, err := c.UpdateProduct(ctx, product)
+	return err
+}
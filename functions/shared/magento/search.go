@@ -0,0 +1,71 @@
+package magento
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Filter is one searchCriteria condition, e.g. {Field: "sku", Value: "bag-%",
+// ConditionType: "like"}. ConditionType defaults to "eq".
+type Filter struct {
+	Field         string
+	Value         string
+	ConditionType string
+}
+
+// SearchCriteria selects products. All filters must match.
+type SearchCriteria struct {
+	Filters  []Filter
+	PageSize int
+}
+
+type productSearchResponse struct {
+	Items      []Product `json:"items"`
+	TotalCount int       `json:"total_count"`
+}
+
+const defaultPageSize = 100
+
+// query encodes the criteria for the given page in Magento's
+// searchCriteria[filter_groups][i][filters][0][...] format. Each filter gets
+// its own group, so the filters are ANDed together.
+func (s SearchCriteria) query(page int) string {
+	values := url.Values{}
+	for i, f := range s.Filters {
+		prefix := fmt.Sprintf("searchCriteria[filter_groups][%d][filters][0]", i)
+		conditionType := f.ConditionType
+		if conditionType == "" {
+			conditionType = "eq"
+		}
+		values.Set(prefix+"[field]", f.Field)
+		values.Set(prefix+"[value]", f.Value)
+		values.Set(prefix+"[condition_type]", conditionType)
+	}
+
+	pageSize := s.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	values.Set("searchCriteria[pageSize]", strconv.Itoa(pageSize))
+	values.Set("searchCriteria[currentPage]", strconv.Itoa(page))
+	return values.Encode()
+}
+
+// SearchProducts returns every product matching criteria, following pages.
+func (c *Client) SearchProducts(ctx context.Context, criteria SearchCriteria) ([]Product, error) {
+	products := []Product{}
+	for page := 1; ; page++ {
+		var response productSearchResponse
+		if err := c.do(ctx, http.MethodGet, "/V1/products?"+criteria.query(page), nil, &response); err != nil {
+			return nil, err
+		}
+
+		products = append(products, response.Items...)
+		if len(response.Items) == 0 || len(products) >= response.TotalCount {
+			return products, nil
+		}
+	}
+}
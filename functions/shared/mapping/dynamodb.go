@@ -0,0 +1,153 @@
+package mapping
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBStore keeps records in a DynamoDB table whose partition key is the
+// string attribute "pk", set to "<kind>#<key>". FindByProduct queries the
+// global secondary index ProductIndex, keyed by the number attribute
+// "shopify_product_id".
+type DynamoDBStore struct {
+	client *dynamodb.Client
+	table  string
+	now    func() time.Time
+}
+
+// ProductIndex is the global secondary index FindByProduct queries.
+const ProductIndex = "shopify_product_id-index"
+
+// NewDynamoDBStore returns a store for table using the default AWS
+// configuration of the environment.
+func NewDynamoDBStore(ctx context.Context, table string) (*DynamoDBStore, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("mapping: failed to load AWS config: %w", err)
+	}
+	return &DynamoDBStore{client: dynamodb.NewFromConfig(cfg), table: table, now: time.Now}, nil
+}
+
+func (s *DynamoDBStore) Get(ctx context.Context, kind Kind, key string) (*Record, error) {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            primaryKey(kind, key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("mapping: failed to get %s: %w", recordID(kind, key), err)
+	}
+	if out.Item == nil {
+		return nil, ErrNotFound
+	}
+
+	var record Record
+	if err := attributevalue.UnmarshalMap(out.Item, &record); err != nil {
+		return nil, fmt.Errorf("mapping: failed to decode %s: %w", recordID(kind, key), err)
+	}
+	return &record, nil
+}
+
+// Put writes the record in a single update so CreatedAt survives without a
+// read first.
+func (s *DynamoDBStore) Put(ctx context.Context, record Record) error {
+	now := s.now()
+	record.UpdatedAt = now
+
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return fmt.Errorf("mapping: failed to encode %s: %w", recordID(record.Kind, record.Key), err)
+	}
+	delete(item, "created_at")
+
+	names := map[string]string{"#created_at": "created_at"}
+	values := map[string]types.AttributeValue{}
+	expression := "SET #created_at = if_not_exists(#created_at, :created_at)"
+	values[":created_at"], err = attributevalue.Marshal(now)
+	if err != nil {
+		return err
+	}
+
+	// Optional fields left empty are removed from the stored item.
+	remove := []string{}
+	for _, name := range []string{"shopify_id", "shopify_product_id", "magento_sku", "magento_id", "source", "hash"} {
+		if _, ok := item[name]; !ok {
+			names["#"+name] = name
+			remove = append(remove, "#"+name)
+		}
+	}
+	for name, value := range item {
+		names["#"+name] = name
+		values[":"+name] = value
+		expression += fmt.Sprintf(", #%s = :%s", name, name)
+	}
+	if len(remove) > 0 {
+		expression += " REMOVE " + strings.Join(remove, ", ")
+	}
+
+	_, err = s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       primaryKey(record.Kind, record.Key),
+		UpdateExpression:          aws.String(expression),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		return fmt.Errorf("mapping: failed to put %s: %w", recordID(record.Kind, record.Key), err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStore) Delete(ctx context.Context, kind Kind, key string) error {
+	_, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.table),
+		Key:       primaryKey(kind, key),
+	})
+	if err != nil {
+		return fmt.Errorf("mapping: failed to delete %s: %w", recordID(kind, key), err)
+	}
+	return nil
+}
+
+// FindByProduct reads from a global secondary index, so a record written
+// moments ago may not be returned yet.
+func (s *DynamoDBStore) FindByProduct(ctx context.Context, productID int64) ([]Record, error) {
+	paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:                aws.String(s.table),
+		IndexName:                aws.String(ProductIndex),
+		KeyConditionExpression:   aws.String("#shopify_product_id = :shopify_product_id"),
+		ExpressionAttributeNames: map[string]string{"#shopify_product_id": "shopify_product_id"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":shopify_product_id": &types.AttributeValueMemberN{Value: strconv.FormatInt(productID, 10)},
+		},
+	})
+
+	records := []Record{}
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("mapping: failed to find records of product %d: %w", productID, err)
+		}
+		var items []Record
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("mapping: failed to decode records of product %d: %w", productID, err)
+		}
+		records = append(records, items...)
+	}
+	return records, nil
+}
+
+func primaryKey(kind Kind, key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"pk": &types.AttributeValueMemberS{Value: recordID(kind, key)},
+	}
+}
@@ -0,0 +1,117 @@
+package mapping
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileStore keeps records in a JSON file, read and rewritten on every call.
+// It suits local runs and tests, not concurrent writers in several processes.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+	now  func() time.Time
+}
+
+// NewFileStore returns a store backed by the file at path, created on the
+// first Put.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path, now: time.Now}
+}
+
+func (s *FileStore) Get(ctx context.Context, kind Kind, key string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	record, ok := records[recordID(kind, key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &record, nil
+}
+
+func (s *FileStore) Put(ctx context.Context, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.load()
+	if err != nil {
+		return err
+	}
+	records[recordID(record.Kind, record.Key)] = stamp(records, record, s.now())
+	return s.save(records)
+}
+
+func (s *FileStore) Delete(ctx context.Context, kind Kind, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.load()
+	if err != nil {
+		return err
+	}
+	delete(records, recordID(kind, key))
+	return s.save(records)
+}
+
+func (s *FileStore) FindByProduct(ctx context.Context, productID int64) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return recordsOfProduct(records, productID), nil
+}
+
+func (s *FileStore) load() (map[string]Record, error) {
+	records := map[string]Record{}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mapping: failed to read %s: %w", s.path, err)
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("mapping: failed to parse %s: %w", s.path, err)
+	}
+	return records, nil
+}
+
+// save writes the records to a temporary file and renames it over the store,
+// so a crash never leaves a half-written file behind.
+func (s *FileStore) save(records map[string]Record) error {
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("mapping: failed to encode records: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("mapping: failed to write %s: %w", s.path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("mapping: failed to write %s: %w", s.path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("mapping: failed to write %s: %w", s.path, err)
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,107 @@
+// Package mapping records which Shopify object became which Magento object,
+// so syncs can address Magento directly instead of probing for it.
+package mapping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Kind is the type of object a Record maps.
+type Kind string
+
+const (
+	// KindVariant records are keyed by Shopify variant ID.
+	KindVariant Kind = "variant"
+	// KindProduct records are keyed by Shopify product ID and map a
+	// configurable parent.
+	KindProduct Kind = "product"
+	// KindOrder records are keyed by Magento order ID.
+	KindOrder Kind = "order"
+	// KindImage records are keyed by Shopify image ID and remember the
+	// content hash of the image last downloaded from Source.
+	KindImage Kind = "image"
+	// KindGallery records are keyed by Magento SKU and remember the hash of
+	// the media gallery last synced to it.
+	KindGallery Kind = "gallery"
+	// KindStoreView records are keyed by store view code and Magento SKU and
+	// remember the hash of the store-scoped update last sent.
+	KindStoreView Kind = "store_view"
+)
+
+// ErrNotFound is returned by Store.Get when no record exists.
+var ErrNotFound = errors.New("mapping: record not found")
+
+// Record links one Shopify object to one Magento object.
+type Record struct {
+	Kind Kind   `json:"kind" dynamodbav:"kind"`
+	Key  string `json:"key" dynamodbav:"key"`
+
+	ShopifyID        int64  `json:"shopify_id,omitempty" dynamodbav:"shopify_id,omitempty"`
+	ShopifyProductID int64  `json:"shopify_product_id,omitempty" dynamodbav:"shopify_product_id,omitempty"`
+	MagentoSKU       string `json:"magento_sku,omitempty" dynamodbav:"magento_sku,omitempty"`
+	MagentoID        string `json:"magento_id,omitempty" dynamodbav:"magento_id,omitempty"`
+
+	// Source is the URL the object was read from, for images.
+	Source string `json:"source,omitempty" dynamodbav:"source,omitempty"`
+
+	// SpecialPrice is the sale price last sent, for variants and store views.
+	SpecialPrice string `json:"special_price,omitempty" dynamodbav:"special_price,omitempty"`
+
+	// Hash identifies the content last synced, so unchanged objects can be
+	// skipped.
+	Hash string `json:"hash,omitempty" dynamodbav:"hash,omitempty"`
+
+	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" dynamodbav:"updated_at"`
+}
+
+// Store persists records. Put creates or replaces the record with the same
+// Kind and Key; it sets UpdatedAt and keeps the stored CreatedAt.
+// FindByProduct returns every record whose ShopifyProductID is productID, in
+// no particular order.
+type Store interface {
+	Get(ctx context.Context, kind Kind, key string) (*Record, error)
+	Put(ctx context.Context, record Record) error
+	Delete(ctx context.Context, kind Kind, key string) error
+	FindByProduct(ctx context.Context, productID int64) ([]Record, error)
+}
+
+// IsNotFound reports whether err means the record does not exist.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// NewFromEnv returns the store selected by MAPPING_STORE: "dynamodb" uses the
+// table named by MAPPING_TABLE, "file" the JSON file at MAPPING_FILE_PATH and
+// "memory", the default, keeps records for the life of the process.
+func NewFromEnv() (Store, error) {
+	switch mode := os.Getenv("MAPPING_STORE"); mode {
+	case "", "memory":
+		return NewMemoryStore(), nil
+
+	case "file":
+		path := os.Getenv("MAPPING_FILE_PATH")
+		if path == "" {
+			return nil, fmt.Errorf("MAPPING_FILE_PATH environment variable not set")
+		}
+		return NewFileStore(path), nil
+
+	case "dynamodb":
+		table := os.Getenv("MAPPING_TABLE")
+		if table == "" {
+			return nil, fmt.Errorf("MAPPING_TABLE environment variable not set")
+		}
+		return NewDynamoDBStore(context.Background(), table)
+
+	default:
+		return nil, fmt.Errorf("unknown MAPPING_STORE: %s", mode)
+	}
+}
+
+func recordID(kind Kind, key string) string {
+	return string(kind) + "#" + key
+}
@@ -0,0 +1,75 @@
+package mapping
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps records in memory. It is meant for tests and local runs;
+// in Lambda its records only live as long as the instance.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+	now     func() time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: map[string]Record{}, now: time.Now}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, kind Kind, key string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[recordID(kind, key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &record, nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[recordID(record.Kind, record.Key)] = stamp(s.records, record, s.now())
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, kind Kind, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, recordID(kind, key))
+	return nil
+}
+
+func (s *MemoryStore) FindByProduct(ctx context.Context, productID int64) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return recordsOfProduct(s.records, productID), nil
+}
+
+// recordsOfProduct returns the records in records that belong to productID.
+func recordsOfProduct(records map[string]Record, productID int64) []Record {
+	found := []Record{}
+	for _, record := range records {
+		if record.ShopifyProductID == productID {
+			found = append(found, record)
+		}
+	}
+	return found
+}
+
+// stamp sets the timestamps of a record about to replace the one in records.
+func stamp(records map[string]Record, record Record, now time.Time) Record {
+	record.CreatedAt = now
+	if existing, ok := records[recordID(record.Kind, record.Key)]; ok {
+		record.CreatedAt = existing.CreatedAt
+	}
+	record.UpdatedAt = now
+	return record
+}
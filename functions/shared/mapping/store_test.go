@@ -0,0 +1,110 @@
+package mapping
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return NewMemoryStore() },
+		"file": func(t *testing.T) Store {
+			return NewFileStore(filepath.Join(t.TempDir(), "mapping.json"))
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+
+			records := []Record{
+				{Kind: KindVariant, Key: "11", ShopifyID: 11, ShopifyProductID: 1, MagentoSKU: "bag-black"},
+				{Kind: KindVariant, Key: "12", ShopifyID: 12, ShopifyProductID: 1, MagentoSKU: "bag-blue"},
+				{Kind: KindProduct, Key: "1", ShopifyID: 1, ShopifyProductID: 1, MagentoSKU: "bag"},
+				{Kind: KindVariant, Key: "21", ShopifyID: 21, ShopifyProductID: 2, MagentoSKU: "wallet"},
+			}
+			for _, record := range records {
+				if err := store.Put(ctx, record); err != nil {
+					t.Fatalf("Put(%s) error = %v", record.Key, err)
+				}
+			}
+
+			got, err := store.Get(ctx, KindVariant, "12")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got.MagentoSKU != "bag-blue" || got.CreatedAt.IsZero() || got.UpdatedAt.IsZero() {
+				t.Errorf("Get() = %+v, want bag-blue with timestamps", got)
+			}
+
+			found, err := store.FindByProduct(ctx, 1)
+			if err != nil {
+				t.Fatalf("FindByProduct() error = %v", err)
+			}
+			if want := []string{"bag", "bag-black", "bag-blue"}; !equal(skus(found), want) {
+				t.Errorf("FindByProduct(1) SKUs = %v, want %v", skus(found), want)
+			}
+
+			found, err = store.FindByProduct(ctx, 3)
+			if err != nil || len(found) != 0 {
+				t.Errorf("FindByProduct(3) = %v, %v, want no records", found, err)
+			}
+
+			if err := store.Delete(ctx, KindVariant, "12"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := store.Get(ctx, KindVariant, "12"); !IsNotFound(err) {
+				t.Errorf("Get() after Delete error = %v, want ErrNotFound", err)
+			}
+			found, _ = store.FindByProduct(ctx, 1)
+			if want := []string{"bag", "bag-black"}; !equal(skus(found), want) {
+				t.Errorf("FindByProduct(1) after Delete SKUs = %v, want %v", skus(found), want)
+			}
+		})
+	}
+}
+
+func TestPutKeepsCreatedAt(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	record := Record{Kind: KindVariant, Key: "11", MagentoSKU: "bag-black"}
+	store.Put(ctx, record)
+	created := now
+
+	now = now.Add(time.Hour)
+	record.Hash = "abc"
+	store.Put(ctx, record)
+
+	got, _ := store.Get(ctx, KindVariant, "11")
+	if !got.CreatedAt.Equal(created) || !got.UpdatedAt.Equal(now) || got.Hash != "abc" {
+		t.Errorf("Get() = %+v, want CreatedAt %v, UpdatedAt %v and the new hash", got, created, now)
+	}
+}
+
+func skus(records []Record) []string {
+	skus := make([]string, 0, len(records))
+	for _, record := range records {
+		skus = append(skus, record.MagentoSKU)
+	}
+	sort.Strings(skus)
+	return skus
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
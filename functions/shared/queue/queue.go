@@ -0,0 +1,60 @@
+// Package queue hands work from a fast, API-facing Lambda to a worker.
+package queue
+
+import (
+	"context"
+	"os"
+	"sync"
+)
+
+// Message is a queued unit of work.
+type Message struct {
+	Body []byte
+	// GroupID orders messages on FIFO queues; it is ignored elsewhere.
+	GroupID string
+	// DeduplicationID suppresses repeated sends on FIFO queues.
+	DeduplicationID string
+}
+
+// Queue accepts messages for asynchronous processing.
+type Queue interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// NewFromEnv returns an SQS queue for SYNC_QUEUE_URL, or an in-memory queue
+// when it is unset.
+func NewFromEnv() (Queue, error) {
+	if queueURL := os.Getenv("SYNC_QUEUE_URL"); queueURL != "" {
+		return NewSQSQueue(context.Background(), queueURL)
+	}
+	return NewMemoryQueue(), nil
+}
+
+// MemoryQueue keeps sent messages in memory, for tests and local runs.
+type MemoryQueue struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemoryQueue returns an empty MemoryQueue.
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{}
+}
+
+func (q *MemoryQueue) Send(ctx context.Context, message Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.messages = append(q.messages, message)
+	return nil
+}
+
+// Drain returns the queued messages and empties the queue.
+func (q *MemoryQueue) Drain() []Message {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	messages := q.messages
+	q.messages = nil
+	return messages
+}
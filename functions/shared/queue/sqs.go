@@ -0,0 +1,50 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// SQSQueue sends messages to an Amazon SQS queue.
+type SQSQueue struct {
+	client   *sqs.Client
+	queueURL string
+	fifo     bool
+}
+
+// NewSQSQueue returns a queue for queueURL using the default AWS
+// configuration of the environment.
+func NewSQSQueue(ctx context.Context, queueURL string) (*SQSQueue, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("queue: failed to load AWS config: %w", err)
+	}
+	return &SQSQueue{
+		client:   sqs.NewFromConfig(cfg),
+		queueURL: queueURL,
+		fifo:     strings.HasSuffix(queueURL, ".fifo"),
+	}, nil
+}
+
+func (q *SQSQueue) Send(ctx context.Context, message Message) error {
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(q.queueURL),
+		MessageBody: aws.String(string(message.Body)),
+	}
+	if q.fifo {
+		input.MessageGroupId = aws.String(message.GroupID)
+		if message.DeduplicationID != "" {
+			input.MessageDeduplicationId = aws.String(message.DeduplicationID)
+		}
+	}
+
+	if _, err := q.client.SendMessage(ctx, input); err != nil {
+		return fmt.Errorf("queue: failed to send message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+)
+
+// IsTransient reports whether the operation that returned err is worth trying
+// again later. API errors decide for themselves through a Transient method;
+// timeouts, cancellations and network failures are transient; anything else
+// (validation errors, bad requests) is permanent.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var classified interface{ Transient() bool }
+	if errors.As(err, &classified) {
+		return classified.Transient()
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return true
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+	return errors.As(err, &urlErr) || errors.As(err, &netErr)
+}
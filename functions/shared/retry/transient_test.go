@@ -0,0 +1,36 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+)
+
+type classifiedError struct{ transient bool }
+
+func (e classifiedError) Error() string   { return "classified" }
+func (e classifiedError) Transient() bool { return e.transient }
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "plain error", err: errors.New("invalid product"), want: false},
+		{name: "classified transient", err: fmt.Errorf("sync: %w", classifiedError{transient: true}), want: true},
+		{name: "classified permanent", err: classifiedError{transient: false}, want: false},
+		{name: "deadline", err: fmt.Errorf("sync: %w", context.DeadlineExceeded), want: true},
+		{name: "canceled", err: context.Canceled, want: true},
+		{name: "network", err: &url.Error{Op: "Get", URL: "https://shop", Err: errors.New("connection reset")}, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("IsTransient(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Package retry provides an http.RoundTripper that retries transient failures.
+package retry
+
+import (
+	"context"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults used when the corresponding Transport field is zero.
+const (
+	DefaultMaxAttempts    = 4
+	DefaultBaseDelay      = 250 * time.Millisecond
+	DefaultMaxDelay       = 8 * time.Second
+	DefaultMaxElapsed     = 30 * time.Second
+	DefaultAttemptTimeout = 10 * time.Second
+	DefaultDeadlineMargin = 2 * time.Second
+)
+
+// IdempotencyKeyHeader marks a non-idempotent request (e.g. a POST) as safe to
+// retry after a server error.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Transport retries requests with jittered exponential backoff.
+//
+// 429 responses are retried for every method, since the server rejected the
+// request without acting on it. Network errors and 5xx responses are only
+// retried for idempotent requests. A Retry-After header overrides the backoff
+// delay. No retry is scheduled that would end past MaxElapsed or too close to
+// the request context's deadline.
+type Transport struct {
+	// Base performs the individual attempts. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// MaxElapsed caps the total time spent on a request, including waits.
+	MaxElapsed time.Duration
+	// AttemptTimeout bounds each individual attempt.
+	AttemptTimeout time.Duration
+	// DeadlineMargin is kept free before the context deadline so the caller
+	// still has time to record the outcome.
+	DeadlineMargin time.Duration
+}
+
+// NewTransport returns a Transport with default settings wrapping base.
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// NewClient returns an http.Client whose requests go through a default Transport.
+// Timeouts are enforced per attempt by the transport rather than by the client.
+func NewClient() *http.Client {
+	return &http.Client{Transport: NewTransport(http.DefaultTransport)}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		attemptReq, cancel, err := t.prepareAttempt(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.base().RoundTrip(attemptReq)
+		if !t.shouldRetry(req, resp, err) || attempt >= t.maxAttempts() || req.Body != nil && req.GetBody == nil {
+			return finishAttempt(resp, err, cancel)
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+			}
+		}
+
+		if !t.canWait(ctx, start, delay) {
+			return finishAttempt(resp, err, cancel)
+		}
+
+		var reason string
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		cancel()
+
+		log.Printf("🔁 Retrying %s %s in %s (attempt %d): %s\n", req.Method, req.URL.Path, delay.Round(time.Millisecond), attempt, reason)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// prepareAttempt clones req with a fresh body and a per-attempt timeout.
+func (t *Transport) prepareAttempt(req *http.Request, attempt int) (*http.Request, context.CancelFunc, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.attemptTimeout())
+	attemptReq := req.Clone(ctx)
+
+	if attempt > 1 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		attemptReq.Body = body
+	}
+	return attemptReq, cancel, nil
+}
+
+// finishAttempt hands the final response to the caller. The attempt context
+// stays alive until the response body is closed.
+func finishAttempt(resp *http.Response, err error, cancel context.CancelFunc) (*http.Response, error) {
+	if err != nil || resp == nil {
+		cancel()
+		return resp, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+func (t *Transport) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		return isIdempotent(req)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return isIdempotent(req)
+	}
+	return false
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// backoff returns a full-jitter exponential delay for the given attempt.
+func (t *Transport) backoff(attempt int) time.Duration {
+	ceiling := t.baseDelay() << (attempt - 1)
+	if ceiling <= 0 || ceiling > t.maxDelay() {
+		ceiling = t.maxDelay()
+	}
+	return time.Duration(rand.Int64N(int64(ceiling)) + 1)
+}
+
+// canWait reports whether waiting delay and trying again still fits within
+// both MaxElapsed and the context deadline.
+func (t *Transport) canWait(ctx context.Context, start time.Time, delay time.Duration) bool {
+	next := time.Now().Add(delay)
+	if next.Sub(start) > t.maxElapsed() {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && next.After(deadline.Add(-t.deadlineMargin())) {
+		return false
+	}
+	return true
+}
+
+// parseRetryAfter reads a Retry-After header given either in (possibly
+// fractional) seconds, as Shopify sends it, or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return time.Duration(seconds * float64(time.Second)), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func (t *Transport) maxAttempts() int {
+	if t.MaxAttempts > 0 {
+		return t.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
+
+func (t *Transport) baseDelay() time.Duration {
+	if t.BaseDelay > 0 {
+		return t.BaseDelay
+	}
+	return DefaultBaseDelay
+}
+
+func (t *Transport) maxDelay() time.Duration {
+	if t.MaxDelay > 0 {
+		return t.MaxDelay
+	}
+	return DefaultMaxDelay
+}
+
+func (t *Transport) maxElapsed() time.Duration {
+	if t.MaxElapsed > 0 {
+		return t.MaxElapsed
+	}
+	return DefaultMaxElapsed
+}
+
+func (t *Transport) attemptTimeout() time.Duration {
+	if t.AttemptTimeout > 0 {
+		return t.AttemptTimeout
+	}
+	return DefaultAttemptTimeout
+}
+
+func (t *Transport) deadlineMargin() time.Duration {
+	if t.DeadlineMargin > 0 {
+		return t.DeadlineMargin
+	}
+	return DefaultDeadlineMargin
+}
@@ -0,0 +1,129 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sequenceServer answers the n-th request with statuses[n], repeating the
+// last status, and records the bodies it received.
+func sequenceServer(t *testing.T, statuses []int, header http.Header) (*httptest.Server, *[]string) {
+	t.Helper()
+	bodies := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		status := statuses[min(len(bodies), len(statuses))-1]
+		for name, values := range header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &bodies
+}
+
+func TestTransportRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		idempotent   bool
+		statuses     []int
+		header       http.Header
+		wantStatus   int
+		wantAttempts int
+	}{
+		{name: "GET retries server errors", method: http.MethodGet, statuses: []int{503, 502, 200}, wantStatus: 200, wantAttempts: 3},
+		{name: "GET gives up after max attempts", method: http.MethodGet, statuses: []int{500}, wantStatus: 500, wantAttempts: DefaultMaxAttempts},
+		{name: "GET does not retry client errors", method: http.MethodGet, statuses: []int{400}, wantStatus: 400, wantAttempts: 1},
+		{name: "PUT retries and resends the body", method: http.MethodPut, statuses: []int{504, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "POST does not retry server errors", method: http.MethodPost, statuses: []int{503, 200}, wantStatus: 503, wantAttempts: 1},
+		{name: "POST with idempotency key retries", method: http.MethodPost, idempotent: true, statuses: []int{503, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "POST retries rate limits", method: http.MethodPost, statuses: []int{429, 200}, header: http.Header{"Retry-After": {"0.01"}}, wantStatus: 200, wantAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, bodies := sequenceServer(t, tt.statuses, tt.header)
+			client := &http.Client{Transport: &Transport{BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}}
+
+			req, err := http.NewRequest(tt.method, server.URL, strings.NewReader(`{"sku": "bag"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.idempotent {
+				req.Header.Set(IdempotencyKeyHeader, "order-1")
+			}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if len(*bodies) != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", len(*bodies), tt.wantAttempts)
+			}
+			for i, body := range *bodies {
+				if body != `{"sku": "bag"}` {
+					t.Errorf("attempt %d body = %q, want the original body", i+1, body)
+				}
+			}
+		})
+	}
+}
+
+func TestTransportStopsBeforeDeadline(t *testing.T) {
+	server, bodies := sequenceServer(t, []int{429}, http.Header{"Retry-After": {"10"}})
+	client := &http.Client{Transport: NewTransport(nil)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != 429 || len(*bodies) != 1 {
+		t.Errorf("got %d after %d attempts, want the first 429", resp.StatusCode, len(*bodies))
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, want no wait past the deadline", elapsed)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{value: "2", want: 2 * time.Second, ok: true},
+		{value: "1.5", want: 1500 * time.Millisecond, ok: true},
+		{value: " 0 ", want: 0, ok: true},
+		{value: "Mon, 01 Jan 2001 00:00:00 GMT", want: 0, ok: true},
+		{value: "", ok: false},
+		{value: "-1", ok: false},
+		{value: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Package shopify is a small client for the Shopify Admin API.
+package shopify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"mokobara-middleware/shared/retry"
+)
+
+// apiVersionPattern matches Admin API versions such as 2025-07. There is no
+// default: Shopify retires each version about a year after its release, so a
+// version baked into the code would silently go stale.
+var apiVersionPattern = regexp.MustCompile(`^\d{4}-\d{2}$`)
+
+// Config configures a Client.
+type Config struct {
+	// StoreName is the myshopify.com subdomain of the store.
+	StoreName string
+	// Token is the Admin API access token.
+	Token string
+	// APIVersion is the Admin API version, e.g. 2025-07. Required.
+	APIVersion string
+	// BaseURL overrides https://{StoreName}.myshopify.com, e.g. to point at a
+	// local test server.
+	BaseURL string
+	// HTTPClient is used for every request. Defaults to a client that retries
+	// transient failures with backoff.
+	HTTPClient *http.Client
+}
+
+// Client calls the Shopify Admin API.
+type Client struct {
+	baseURL    string
+	token      string
+	apiVersion string
+	httpClient *http.Client
+}
+
+// NewClient returns a Client for cfg.
+func NewClient(cfg Config) (*Client, error) {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		if cfg.StoreName == "" {
+			return nil, fmt.Errorf("shopify: store name or base URL is required")
+		}
+		baseURL = fmt.Sprintf("https://%s.myshopify.com", cfg.StoreName)
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("shopify: token is required")
+	}
+
+	apiVersion := cfg.APIVersion
+	if apiVersion == "" {
+		return nil, fmt.Errorf("shopify: API version is required")
+	}
+	if !apiVersionPattern.MatchString(apiVersion) {
+		return nil, fmt.Errorf("shopify: invalid API version %q, want YYYY-MM", apiVersion)
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Transport: retry.NewTransport(&callLimitTransport{base: http.DefaultTransport}),
+		}
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      cfg.Token,
+		apiVersion: apiVersion,
+		httpClient: httpClient,
+	}, nil
+}
+
+// NewFromEnv returns a Client configured from STORE_NAME, SHOPIFY_TOKEN,
+// SHOPIFY_API_VERSION and SHOPIFY_BASE_URL.
+func NewFromEnv() (*Client, error) {
+	token := os.Getenv("SHOPIFY_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("SHOPIFY_TOKEN environment variable not set")
+	}
+	apiVersion := os.Getenv("SHOPIFY_API_VERSION")
+	if apiVersion == "" {
+		return nil, fmt.Errorf("SHOPIFY_API_VERSION environment variable not set")
+	}
+
+	return NewClient(Config{
+		StoreName:  os.Getenv("STORE_NAME"),
+		Token:      token,
+		APIVersion: apiVersion,
+		BaseURL:    os.Getenv("SHOPIFY_BASE_URL"),
+	})
+}
+
+// APIVersion returns the Admin API version the client targets.
+func (c *Client) APIVersion() string {
+	return c.apiVersion
+}
+
+// do sends a JSON request to path (relative to /admin/api/{version}) and
+// decodes a successful response into out, if out is non-nil. Non-2xx
+// responses are returned as *Error.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	_, err := c.send(ctx, method, path, in, out)
+	return err
+}
+
+// send is do but also returns the response headers, e.g. for pagination.
+func (c *Client) send(ctx context.Context, method, path string, in, out interface{}) (http.Header, error) {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return nil, fmt.Errorf("shopify: failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	endpoint := fmt.Sprintf("%s/admin/api/%s%s", c.baseURL, c.apiVersion, path)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("shopify: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Shopify-Access-Token", c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("shopify: %s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("shopify: failed to read response: %w", err)
+	}
+
+	log.Printf("🌐 Shopify %s %s: %d\n", method, path, resp.StatusCode)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &Error{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Body:       string(respBody),
+		}
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return resp.Header, nil
+	}
+	return resp.Header, Decode(respBody, out)
+}
+
+// nextPageInfo returns the page_info cursor of the rel="next" page in a
+// REST Link header, or "" on the last page.
+func nextPageInfo(header http.Header) string {
+	for _, link := range strings.Split(header.Get("Link"), ",") {
+		target, rel, ok := strings.Cut(link, ";")
+		if !ok || !strings.Contains(rel, `rel="next"`) {
+			continue
+		}
+		target = strings.Trim(strings.TrimSpace(target), "<>")
+		if u, err := url.Parse(target); err == nil {
+			return u.Query().Get("page_info")
+		}
+	}
+	return ""
+}
+
+// graphQLResponse is the envelope of every GraphQL Admin API response.
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphql runs a GraphQL Admin API query and decodes its data into out.
+func (c *Client) graphql(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	request := map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	}
+
+	var response graphQLResponse
+	if err := c.do(ctx, http.MethodPost, "/graphql.json", request, &response); err != nil {
+		return err
+	}
+
+	if len(response.Errors) > 0 {
+		messages := make([]string, 0, len(response.Errors))
+		for _, e := range response.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("shopify: graphql query failed: %s", strings.Join(messages, "; "))
+	}
+
+	return Decode(response.Data, out)
+}
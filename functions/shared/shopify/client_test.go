@@ -0,0 +1,279 @@
+package shopify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAPIVersion = "2025-07"
+
+// testRequest is a request received by newTestClient's server.
+type testRequest struct {
+	Method string
+	URI    string
+	Token  string
+	Body   string
+}
+
+// newTestClient returns a client whose requests are answered by handler and
+// recorded in requests.
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *[]testRequest) {
+	t.Helper()
+	requests := []testRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, testRequest{
+			Method: r.Method,
+			URI:    r.RequestURI,
+			Token:  r.Header.Get("X-Shopify-Access-Token"),
+			Body:   string(body),
+		})
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(Config{BaseURL: server.URL, Token: "shpat_test", APIVersion: testAPIVersion, HTTPClient: server.Client()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, &requests
+}
+
+func TestNewClientAPIVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{version: "2025-07"},
+		{version: "", wantErr: true},
+		{version: "latest", wantErr: true},
+		{version: "2025-7", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		_, err := NewClient(Config{StoreName: "shop", Token: "t", APIVersion: tt.version})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewClient(APIVersion %q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNewFromEnvRequiresAPIVersion(t *testing.T) {
+	t.Setenv("SHOPIFY_TOKEN", "t")
+	t.Setenv("STORE_NAME", "shop")
+	t.Setenv("SHOPIFY_API_VERSION", "")
+	if _, err := NewFromEnv(); err == nil {
+		t.Error("NewFromEnv() error = nil, want an error without SHOPIFY_API_VERSION")
+	}
+
+	t.Setenv("SHOPIFY_API_VERSION", testAPIVersion)
+	client, err := NewFromEnv()
+	if err != nil || client.APIVersion() != testAPIVersion {
+		t.Errorf("NewFromEnv() = %v, %v, want version %s", client, err, testAPIVersion)
+	}
+}
+
+func TestGetProduct(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"product": {"id": 7, "title": "Bag", "handle": "bag", "variants": [{"id": 11, "sku": "BAG-01", "price": "10.00"}]}}`))
+	})
+
+	product, err := client.GetProduct(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("GetProduct() error = %v", err)
+	}
+	if product.ID != 7 || product.Handle != "bag" || len(product.Variants) != 1 || product.Variants[0].SKU != "BAG-01" {
+		t.Errorf("GetProduct() = %+v", product)
+	}
+
+	got := (*requests)[0]
+	if got.Method != http.MethodGet || got.URI != "/admin/api/2025-07/products/7.json" || got.Token != "shpat_test" {
+		t.Errorf("request = %+v, want GET /admin/api/2025-07/products/7.json with the token", got)
+	}
+}
+
+func TestGetProductErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		wantNotFound  bool
+		wantTransient bool
+	}{
+		{name: "not found", status: http.StatusNotFound, wantNotFound: true},
+		{name: "rate limited", status: http.StatusTooManyRequests, wantTransient: true},
+		{name: "server error", status: http.StatusBadGateway, wantTransient: true},
+		{name: "forbidden", status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"errors": "nope"}`))
+			})
+
+			_, err := client.GetProduct(context.Background(), 7)
+			apiErr, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("GetProduct() error = %v, want *Error", err)
+			}
+			if IsNotFound(err) != tt.wantNotFound || apiErr.Transient() != tt.wantTransient {
+				t.Errorf("IsNotFound = %v, Transient = %v, want %v, %v", IsNotFound(err), apiErr.Transient(), tt.wantNotFound, tt.wantTransient)
+			}
+		})
+	}
+}
+
+func TestGetProductMetafieldsFollowsPages(t *testing.T) {
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page_info") == "" {
+			w.Header().Set("Link", `<https://shop.myshopify.com/admin/api/2025-07/products/7/metafields.json?limit=250&page_info=abc>; rel="next"`)
+			w.Write([]byte(`{"metafields": [{"namespace": "custom", "key": "material", "type": "single_line_text_field", "value": "leather"}]}`))
+			return
+		}
+		w.Write([]byte(`{"metafields": [{"namespace": "custom", "key": "is_published", "type": "boolean", "value": true}]}`))
+	})
+
+	metafields, err := client.GetProductMetafields(context.Background(), 7)
+	if err != nil {
+		t.Fatalf("GetProductMetafields() error = %v", err)
+	}
+	if len(metafields) != 2 || metafields[0].FullKey() != "custom.material" || metafields[1].FullKey() != "custom.is_published" {
+		t.Errorf("GetProductMetafields() = %+v", metafields)
+	}
+	if len(*requests) != 2 || !strings.HasSuffix((*requests)[1].URI, "page_info=abc") {
+		t.Errorf("requests = %+v, want the second page requested with its cursor", *requests)
+	}
+}
+
+func TestOrders(t *testing.T) {
+	ctx := context.Background()
+	client, requests := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/graphql.json") {
+			w.Write([]byte(`{"data": {"orders": {"nodes": [{"id": "gid://shopify/Order/55", "name": "#1001", "tags": ["100"]}]}}}`))
+			return
+		}
+		w.Write([]byte(`{"order": {"id": 55, "name": "#1001", "tags": "100"}}`))
+	})
+
+	order := Order{Email: "jane@example.com", Tags: "100", LineItems: []LineItem{{Title: "Bag", Quantity: 1, Price: "10.00"}}}
+	created, err := client.CreateOrder(ctx, order)
+	if err != nil || created.ID != 55 {
+		t.Fatalf("CreateOrder() = %+v, %v", created, err)
+	}
+
+	order.Tags = ""
+	if _, err := client.UpdateOrder(ctx, 55, order); err != nil {
+		t.Fatalf("UpdateOrder() error = %v", err)
+	}
+
+	found, err := client.FindOrdersByTag(ctx, "100")
+	if err != nil || len(found) != 1 || found[0].ID != 55 || found[0].Name != "#1001" {
+		t.Fatalf("FindOrdersByTag() = %+v, %v", found, err)
+	}
+
+	want := []struct{ method, uri string }{
+		{http.MethodPost, "/admin/api/2025-07/orders.json"},
+		{http.MethodPut, "/admin/api/2025-07/orders/55.json"},
+		{http.MethodPost, "/admin/api/2025-07/graphql.json"},
+	}
+	for i, w := range want {
+		if got := (*requests)[i]; got.Method != w.method || got.URI != w.uri {
+			t.Errorf("request %d = %s %s, want %s %s", i, got.Method, got.URI, w.method, w.uri)
+		}
+	}
+
+	var update OrderRequest
+	json.Unmarshal([]byte((*requests)[1].Body), &update)
+	if update.Order.Tags != "" || strings.Contains((*requests)[1].Body, `"tags"`) {
+		t.Errorf("update body = %s, want no tags", (*requests)[1].Body)
+	}
+	var query struct {
+		Variables map[string]string `json:"variables"`
+	}
+	json.Unmarshal([]byte((*requests)[2].Body), &query)
+	if query.Variables["query"] != "tag:'100'" {
+		t.Errorf("graphql variables = %v, want tag:'100'", query.Variables)
+	}
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors": [{"message": "Throttled"}]}`))
+	})
+
+	if _, err := client.FindOrdersByTag(context.Background(), "100"); err == nil || !strings.Contains(err.Error(), "Throttled") {
+		t.Errorf("FindOrdersByTag() error = %v, want the GraphQL error", err)
+	}
+}
+
+func TestCallLimitTransportPaces(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		// 33 of 40 calls used is one call over the threshold, i.e. half a
+		// second of leaking.
+		w.Header().Set(callLimitHeader, "33/40")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	transport := &callLimitTransport{base: http.DefaultTransport}
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	start := time.Now()
+	resp, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("second request after %v, want it paced by about 500ms", elapsed)
+	}
+
+	// A request whose context ends while pacing is not sent.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Error("Do() error = nil, want the context error while pacing")
+	}
+	if calls != 2 {
+		t.Errorf("server calls = %d, want 2", calls)
+	}
+}
+
+func TestParseCallLimit(t *testing.T) {
+	tests := []struct {
+		value       string
+		used, limit int
+		ok          bool
+	}{
+		{value: "32/40", used: 32, limit: 40, ok: true},
+		{value: " 1 / 80 ", used: 1, limit: 80, ok: true},
+		{value: "", ok: false},
+		{value: "32", ok: false},
+		{value: "32/0", ok: false},
+		{value: "a/40", ok: false},
+	}
+
+	for _, tt := range tests {
+		used, limit, ok := parseCallLimit(tt.value)
+		if used != tt.used || limit != tt.limit || ok != tt.ok {
+			t.Errorf("parseCallLimit(%q) = %d, %d, %v, want %d, %d, %v", tt.value, used, limit, ok, tt.used, tt.limit, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package shopify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error is returned for any non-2xx response from the Admin API.
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("shopify: %s %s returned %d: %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// Transient reports whether the request may succeed if retried later:
+// rate limiting, timeouts and server-side errors.
+func (e *Error) Transient() bool {
+	return e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode == http.StatusRequestTimeout ||
+		e.StatusCode >= 500
+}
+
+// IsNotFound reports whether err is a Shopify 404 response.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,125 @@
+package shopify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// InventoryLevel is the available quantity of an inventory item at one location.
+type InventoryLevel struct {
+	LocationID int64
+	Available  int
+}
+
+// InventoryItem links an inventory item to the variant it stocks.
+type InventoryItem struct {
+	ID                int64
+	Tracked           bool
+	VariantID         int64
+	ProductID         int64
+	Handle            string
+	SKU               string
+	InventoryQuantity int
+	Levels            []InventoryLevel
+}
+
+const inventoryItemQuery = `query($id: ID!) {
+  inventoryItem(id: $id) {
+    tracked
+    variant {
+      id
+      sku
+      inventoryQuantity
+      product { id handle }
+    }
+    inventoryLevels(first: 50) {
+      nodes {
+        location { id }
+        quantities(names: ["available"]) { name quantity }
+      }
+    }
+  }
+}`
+
+// GetInventoryItem resolves an inventory item to its variant, product and
+// per-location available quantities.
+func (c *Client) GetInventoryItem(ctx context.Context, inventoryItemID int64) (*InventoryItem, error) {
+	var data struct {
+		InventoryItem *struct {
+			Tracked bool `json:"tracked"`
+			Variant *struct {
+				ID                string `json:"id"`
+				SKU               string `json:"sku"`
+				InventoryQuantity int    `json:"inventoryQuantity"`
+				Product           struct {
+					ID     string `json:"id"`
+					Handle string `json:"handle"`
+				} `json:"product"`
+			} `json:"variant"`
+			InventoryLevels struct {
+				Nodes []struct {
+					Location struct {
+						ID string `json:"id"`
+					} `json:"location"`
+					Quantities []struct {
+						Name     string `json:"name"`
+						Quantity int    `json:"quantity"`
+					} `json:"quantities"`
+				} `json:"nodes"`
+			} `json:"inventoryLevels"`
+		} `json:"inventoryItem"`
+	}
+
+	variables := map[string]interface{}{
+		"id": fmt.Sprintf("gid://shopify/InventoryItem/%d", inventoryItemID),
+	}
+	if err := c.graphql(ctx, inventoryItemQuery, variables, &data); err != nil {
+		return nil, err
+	}
+
+	item := data.InventoryItem
+	if item == nil || item.Variant == nil {
+		return nil, &Error{
+			Method:     http.MethodPost,
+			Path:       "/graphql.json",
+			StatusCode: http.StatusNotFound,
+			Body:       fmt.Sprintf("no variant found for inventory item %d", inventoryItemID),
+		}
+	}
+
+	variantID, err := ParseGID(item.Variant.ID)
+	if err != nil {
+		return nil, err
+	}
+	productID, err := ParseGID(item.Variant.Product.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &InventoryItem{
+		ID:                inventoryItemID,
+		Tracked:           item.Tracked,
+		VariantID:         variantID,
+		ProductID:         productID,
+		Handle:            item.Variant.Product.Handle,
+		SKU:               item.Variant.SKU,
+		InventoryQuantity: item.Variant.InventoryQuantity,
+	}
+
+	for _, node := range item.InventoryLevels.Nodes {
+		locationID, err := ParseGID(node.Location.ID)
+		if err != nil {
+			return nil, err
+		}
+		level := InventoryLevel{LocationID: locationID}
+		for _, q := range node.Quantities {
+			if q.Name == "available" {
+				level.Available = q.Quantity
+			}
+		}
+		result.Levels = append(result.Levels, level)
+	}
+
+	return result, nil
+}
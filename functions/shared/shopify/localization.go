@@ -0,0 +1,113 @@
+package shopify
+
+import (
+	"context"
+	"fmt"
+)
+
+// VariantPrice is a variant's price in the currency of one market.
+type VariantPrice struct {
+	Price          string
+	CompareAtPrice string
+	CurrencyCode   string
+}
+
+const contextualPricesQuery = `query($id: ID!, $country: CountryCode!) {
+  product(id: $id) {
+    variants(first: 250) {
+      nodes {
+        id
+        contextualPricing(context: {country: $country}) {
+          price { amount currencyCode }
+          compareAtPrice { amount currencyCode }
+        }
+      }
+    }
+  }
+}`
+
+// GetContextualPrices returns the presentment price of every variant of a
+// product for buyers in country (an ISO code such as "AE"), keyed by variant ID.
+func (c *Client) GetContextualPrices(ctx context.Context, productID int64, country string) (map[int64]VariantPrice, error) {
+	type money struct {
+		Amount       string `json:"amount"`
+		CurrencyCode string `json:"currencyCode"`
+	}
+	var data struct {
+		Product *struct {
+			Variants struct {
+				Nodes []struct {
+					ID                string `json:"id"`
+					ContextualPricing struct {
+						Price          money  `json:"price"`
+						CompareAtPrice *money `json:"compareAtPrice"`
+					} `json:"contextualPricing"`
+				} `json:"nodes"`
+			} `json:"variants"`
+		} `json:"product"`
+	}
+
+	variables := map[string]interface{}{
+		"id":      fmt.Sprintf("gid://shopify/Product/%d", productID),
+		"country": country,
+	}
+	if err := c.graphql(ctx, contextualPricesQuery, variables, &data); err != nil {
+		return nil, err
+	}
+	if data.Product == nil {
+		return nil, fmt.Errorf("shopify: product %d not found", productID)
+	}
+
+	prices := map[int64]VariantPrice{}
+	for _, node := range data.Product.Variants.Nodes {
+		variantID, err := ParseGID(node.ID)
+		if err != nil {
+			return nil, err
+		}
+		price := VariantPrice{
+			Price:        node.ContextualPricing.Price.Amount,
+			CurrencyCode: node.ContextualPricing.Price.CurrencyCode,
+		}
+		if compareAt := node.ContextualPricing.CompareAtPrice; compareAt != nil {
+			price.CompareAtPrice = compareAt.Amount
+		}
+		prices[variantID] = price
+	}
+	return prices, nil
+}
+
+const productTranslationsQuery = `query($id: ID!, $locale: String!) {
+  translatableResource(resourceId: $id) {
+    translations(locale: $locale) { key value }
+  }
+}`
+
+// GetProductTranslations returns a product's translated fields for locale,
+// keyed by field name ("title", "body_html", ...). Untranslated fields are
+// absent.
+func (c *Client) GetProductTranslations(ctx context.Context, productID int64, locale string) (map[string]string, error) {
+	var data struct {
+		TranslatableResource *struct {
+			Translations []struct {
+				Key   string `json:"key"`
+				Value string `json:"value"`
+			} `json:"translations"`
+		} `json:"translatableResource"`
+	}
+
+	variables := map[string]interface{}{
+		"id":     fmt.Sprintf("gid://shopify/Product/%d", productID),
+		"locale": locale,
+	}
+	if err := c.graphql(ctx, productTranslationsQuery, variables, &data); err != nil {
+		return nil, err
+	}
+
+	translations := map[string]string{}
+	if data.TranslatableResource != nil {
+		for _, t := range data.TranslatableResource.Translations {
+			translations[t.Key] = t.Value
+		}
+	}
+	return translations, nil
+}
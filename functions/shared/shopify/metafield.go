@@ -0,0 +1,172 @@
+package shopify
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// FullKey returns the "namespace.key" identifier of the metafield.
+func (m Metafield) FullKey() string {
+	return m.Namespace + "." + m.Key
+}
+
+// Text converts the metafield value into the plain text form stored in a
+// Magento attribute:
+//
+//	boolean                        "1" or "0"
+//	number_integer, number_decimal the number
+//	json                           compact JSON
+//	rich_text_field                HTML
+//	*_reference                    the numeric ID of the referenced resource
+//	dimension, weight, volume      "<value> <unit>"
+//	money                          the amount
+//	rating                         the rating value
+//	list.<type>                    the converted items joined with ","
+//
+// Any other type (text, url, color, date, ...) is returned unchanged.
+func (m Metafield) Text() (string, error) {
+	raw := m.StringValue()
+
+	if itemType, ok := strings.CutPrefix(m.Type, "list."); ok {
+		var items []json.RawMessage
+		if err := json.Unmarshal([]byte(raw), &items); err != nil {
+			return "", fmt.Errorf("metafield %s: invalid list value: %w", m.FullKey(), err)
+		}
+
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			value, err := Metafield{Namespace: m.Namespace, Key: m.Key, Type: itemType, Value: item}.Text()
+			if err != nil {
+				return "", err
+			}
+			values = append(values, value)
+		}
+		return strings.Join(values, ","), nil
+	}
+
+	switch {
+	case m.Type == "boolean":
+		value, err := m.BoolValue()
+		if err != nil {
+			return "", err
+		}
+		if value {
+			return "1", nil
+		}
+		return "0", nil
+
+	case m.Type == "number_integer" || m.Type == "number_decimal":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return "", fmt.Errorf("metafield %s: invalid number %q", m.FullKey(), raw)
+		}
+		return raw, nil
+
+	case m.Type == "json":
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return "", fmt.Errorf("metafield %s: invalid json: %w", m.FullKey(), err)
+		}
+		compact, _ := json.Marshal(value)
+		return string(compact), nil
+
+	case m.Type == "rich_text_field":
+		var root richTextNode
+		if err := json.Unmarshal([]byte(raw), &root); err != nil {
+			return "", fmt.Errorf("metafield %s: invalid rich text: %w", m.FullKey(), err)
+		}
+		return root.html(), nil
+
+	case strings.HasSuffix(m.Type, "_reference"):
+		id, err := ParseGID(raw)
+		if err != nil {
+			return "", fmt.Errorf("metafield %s: %w", m.FullKey(), err)
+		}
+		return strconv.FormatInt(id, 10), nil
+
+	case m.Type == "dimension" || m.Type == "weight" || m.Type == "volume":
+		var measurement struct {
+			Value json.Number `json:"value"`
+			Unit  string      `json:"unit"`
+		}
+		if err := json.Unmarshal([]byte(raw), &measurement); err != nil {
+			return "", fmt.Errorf("metafield %s: invalid %s: %w", m.FullKey(), m.Type, err)
+		}
+		return strings.TrimSpace(measurement.Value.String() + " " + measurement.Unit), nil
+
+	case m.Type == "money":
+		var money struct {
+			Amount string `json:"amount"`
+		}
+		if err := json.Unmarshal([]byte(raw), &money); err != nil {
+			return "", fmt.Errorf("metafield %s: invalid money: %w", m.FullKey(), err)
+		}
+		return money.Amount, nil
+
+	case m.Type == "rating":
+		var rating struct {
+			Value string `json:"value"`
+		}
+		if err := json.Unmarshal([]byte(raw), &rating); err != nil {
+			return "", fmt.Errorf("metafield %s: invalid rating: %w", m.FullKey(), err)
+		}
+		return rating.Value, nil
+	}
+
+	return raw, nil
+}
+
+// richTextNode is a node of Shopify's rich text JSON document.
+type richTextNode struct {
+	Type     string         `json:"type"`
+	Value    string         `json:"value"`
+	Level    int            `json:"level"`
+	ListType string         `json:"listType"`
+	URL      string         `json:"url"`
+	Title    string         `json:"title"`
+	Bold     bool           `json:"bold"`
+	Italic   bool           `json:"italic"`
+	Children []richTextNode `json:"children"`
+}
+
+func (n richTextNode) html() string {
+	var children strings.Builder
+	for _, child := range n.Children {
+		children.WriteString(child.html())
+	}
+	inner := children.String()
+
+	switch n.Type {
+	case "root":
+		return inner
+	case "paragraph":
+		return "<p>" + inner + "</p>"
+	case "heading":
+		level := n.Level
+		if level < 1 || level > 6 {
+			level = 2
+		}
+		return fmt.Sprintf("<h%d>%s</h%d>", level, inner, level)
+	case "list":
+		if n.ListType == "ordered" {
+			return "<ol>" + inner + "</ol>"
+		}
+		return "<ul>" + inner + "</ul>"
+	case "list-item":
+		return "<li>" + inner + "</li>"
+	case "link":
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(n.URL), inner)
+	case "text":
+		text := html.EscapeString(n.Value)
+		if n.Bold {
+			text = "<strong>" + text + "</strong>"
+		}
+		if n.Italic {
+			text = "<em>" + text + "</em>"
+		}
+		return text
+	}
+	return inner
+}
@@ -0,0 +1,76 @@
+package shopify
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMetafieldText(t *testing.T) {
+	// quoted encodes a value the way the REST API returns most metafields:
+	// as a JSON string, even when the content is itself JSON.
+	quoted := func(s string) json.RawMessage {
+		data, _ := json.Marshal(s)
+		return data
+	}
+
+	tests := []struct {
+		name      string
+		fieldType string
+		value     json.RawMessage
+		want      string
+		wantErr   bool
+	}{
+		{name: "text", fieldType: "single_line_text_field", value: quoted("Full grain leather"), want: "Full grain leather"},
+		{name: "multi-line text", fieldType: "multi_line_text_field", value: quoted("line one\nline two"), want: "line one\nline two"},
+		{name: "url", fieldType: "url", value: quoted("https://example.com/care"), want: "https://example.com/care"},
+		{name: "boolean", fieldType: "boolean", value: json.RawMessage(`true`), want: "1"},
+		{name: "boolean as string", fieldType: "boolean", value: quoted("false"), want: "0"},
+		{name: "invalid boolean", fieldType: "boolean", value: quoted("yes"), wantErr: true},
+		{name: "integer", fieldType: "number_integer", value: json.RawMessage(`12`), want: "12"},
+		{name: "decimal as string", fieldType: "number_decimal", value: quoted("1.25"), want: "1.25"},
+		{name: "invalid number", fieldType: "number_integer", value: quoted("twelve"), wantErr: true},
+		{name: "json is compacted", fieldType: "json", value: quoted(`{ "care": [ "wipe" ] }`), want: `{"care":["wipe"]}`},
+		{name: "invalid json", fieldType: "json", value: quoted(`{care`), wantErr: true},
+		{
+			name:      "rich text",
+			fieldType: "rich_text_field",
+			value:     quoted(`{"type":"root","children":[{"type":"heading","level":3,"children":[{"type":"text","value":"Care"}]},{"type":"paragraph","children":[{"type":"text","value":"Wipe & dry","bold":true},{"type":"text","value":" only"}]}]}`),
+			want:      "<h3>Care</h3><p><strong>Wipe &amp; dry</strong> only</p>",
+		},
+		{
+			name:      "rich text list and link",
+			fieldType: "rich_text_field",
+			value:     quoted(`{"type":"root","children":[{"type":"list","listType":"ordered","children":[{"type":"list-item","children":[{"type":"link","url":"https://example.com/?a=1&b=2","children":[{"type":"text","value":"Guide","italic":true}]}]}]}]}`),
+			want:      `<ol><li><a href="https://example.com/?a=1&amp;b=2"><em>Guide</em></a></li></ol>`,
+		},
+		{name: "invalid rich text", fieldType: "rich_text_field", value: quoted(`[`), wantErr: true},
+		{name: "product reference", fieldType: "product_reference", value: quoted("gid://shopify/Product/8123"), want: "8123"},
+		{name: "file reference", fieldType: "file_reference", value: quoted("gid://shopify/MediaImage/55"), want: "55"},
+		{name: "invalid reference", fieldType: "variant_reference", value: quoted("gid://shopify/ProductVariant/abc"), wantErr: true},
+		{name: "dimension", fieldType: "dimension", value: quoted(`{"value": 42.5, "unit": "cm"}`), want: "42.5 cm"},
+		{name: "weight", fieldType: "weight", value: quoted(`{"value": 2, "unit": "KILOGRAMS"}`), want: "2 KILOGRAMS"},
+		{name: "volume", fieldType: "volume", value: quoted(`{"value": 35, "unit": "l"}`), want: "35 l"},
+		{name: "money", fieldType: "money", value: quoted(`{"amount": "4999.00", "currency_code": "INR"}`), want: "4999.00"},
+		{name: "rating", fieldType: "rating", value: quoted(`{"value": "4.5", "scale_min": "1.0", "scale_max": "5.0"}`), want: "4.5"},
+		{name: "list of text", fieldType: "list.single_line_text_field", value: quoted(`["navy", "black"]`), want: "navy,black"},
+		{name: "list of integers", fieldType: "list.number_integer", value: quoted(`[1, 2, 3]`), want: "1,2,3"},
+		{name: "list of references", fieldType: "list.product_reference", value: quoted(`["gid://shopify/Product/1", "gid://shopify/Product/2"]`), want: "1,2"},
+		{name: "list of dimensions", fieldType: "list.dimension", value: quoted(`[{"value": 10, "unit": "cm"}, {"value": 20, "unit": "cm"}]`), want: "10 cm,20 cm"},
+		{name: "empty list", fieldType: "list.single_line_text_field", value: quoted(`[]`), want: ""},
+		{name: "list with an invalid item", fieldType: "list.number_integer", value: quoted(`[1, "two"]`), wantErr: true},
+		{name: "invalid list", fieldType: "list.single_line_text_field", value: quoted("navy"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Metafield{Namespace: "custom", Key: "field", Type: tt.fieldType, Value: tt.value}
+			got, err := m.Text()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Text() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package shopify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// OrderRequest is the body of POST /orders.json and PUT /orders/{id}.json.
+type OrderRequest struct {
+	Order Order `json:"order"`
+}
+
+// OrderResponse is the body returned when an order is created or updated.
+type OrderResponse struct {
+	Order Order `json:"order"`
+}
+
+// Order is a Shopify order.
+type Order struct {
+	ID                int64      `json:"id,omitempty"`
+	Name              string     `json:"name,omitempty"`
+	Email             string     `json:"email"`
+	Tags              string     `json:"tags,omitempty"`
+	FulfillmentStatus string     `json:"fulfillment_status"`
+	LineItems         []LineItem `json:"line_items"`
+	ShippingAddress   Address    `json:"shipping_address"`
+	BillingAddress    Address    `json:"billing_address"`
+}
+
+// LineItem is an order line.
+type LineItem struct {
+	Title    string `json:"title"`
+	Quantity int    `json:"quantity"`
+	Price    string `json:"price"`
+}
+
+// Address is a shipping or billing address.
+type Address struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Address1  string `json:"address1"`
+	City      string `json:"city"`
+	Province  string `json:"province"`
+	Country   string `json:"country"`
+	Zip       string `json:"zip"`
+	Phone     string `json:"phone"`
+}
+
+// OrderSummary identifies an existing order.
+type OrderSummary struct {
+	ID   int64
+	Name string
+	Tags []string
+}
+
+const findOrdersByTagQuery = `query($query: String!) {
+  orders(first: 5, query: $query) {
+    nodes { id name tags }
+  }
+}`
+
+// FindOrdersByTag returns the orders carrying the given tag.
+func (c *Client) FindOrdersByTag(ctx context.Context, tag string) ([]OrderSummary, error) {
+	var data struct {
+		Orders struct {
+			Nodes []struct {
+				ID   string   `json:"id"`
+				Name string   `json:"name"`
+				Tags []string `json:"tags"`
+			} `json:"nodes"`
+		} `json:"orders"`
+	}
+
+	variables := map[string]interface{}{
+		"query": fmt.Sprintf("tag:'%s'", strings.ReplaceAll(tag, "'", "\\'")),
+	}
+	if err := c.graphql(ctx, findOrdersByTagQuery, variables, &data); err != nil {
+		return nil, err
+	}
+
+	orders := make([]OrderSummary, 0, len(data.Orders.Nodes))
+	for _, node := range data.Orders.Nodes {
+		id, err := ParseGID(node.ID)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, OrderSummary{ID: id, Name: node.Name, Tags: node.Tags})
+	}
+	return orders, nil
+}
+
+// CreateOrder creates an order and returns it as saved by Shopify.
+func (c *Client) CreateOrder(ctx context.Context, order Order) (*Order, error) {
+	var response OrderResponse
+	if err := c.do(ctx, http.MethodPost, "/orders.json", OrderRequest{Order: order}, &response); err != nil {
+		return nil, err
+	}
+	return &response.Order, nil
+}
+
+// UpdateOrder updates an existing order and returns it as saved by Shopify.
+func (c *Client) UpdateOrder(ctx context.Context, orderID int64, order Order) (*Order, error) {
+	var response OrderResponse
+	path := fmt.Sprintf("/orders/%d.json", orderID)
+	if err := c.do(ctx, http.MethodPut, path, OrderRequest{Order: order}, &response); err != nil {
+		return nil, err
+	}
+	return &response.Order, nil
+}
+
+// ParseGID returns the numeric ID of a GraphQL global ID such as
+// gid://shopify/Order/123.
+func ParseGID(gid string) (int64, error) {
+	idx := strings.LastIndex(gid, "/")
+	id, err := strconv.ParseInt(gid[idx+1:], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("shopify: invalid global ID %q", gid)
+	}
+	return id, nil
+}
@@ -0,0 +1,173 @@
+package shopify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ProductResponse is the body of GET /products/{id}.json.
+type ProductResponse struct {
+	Product Product `json:"product"`
+}
+
+// Product is a Shopify Admin API product.
+type Product struct {
+	ID          int64     `json:"id"`
+	Title       string    `json:"title"`
+	BodyHTML    string    `json:"body_html"`
+	Vendor      string    `json:"vendor"`
+	ProductType string    `json:"product_type"`
+	Handle      string    `json:"handle"`
+	Status      string    `json:"status"`
+	Tags        string    `json:"tags"`
+	Variants    []Variant `json:"variants"`
+	Options     []Option  `json:"options"`
+	Images      []Image   `json:"images"`
+	Image       *Image    `json:"image"`
+	// Metafields is not part of the product resource; it is filled in
+	// separately from the metafields endpoint.
+	Metafields []Metafield `json:"metafields,omitempty"`
+}
+
+// Variant is a single purchasable variant of a product.
+type Variant struct {
+	ID                int64   `json:"id"`
+	ProductID         int64   `json:"product_id"`
+	Title             string  `json:"title"`
+	SKU               string  `json:"sku"`
+	Barcode           string  `json:"barcode"`
+	Price             string  `json:"price"`
+	CompareAtPrice    string  `json:"compare_at_price"`
+	Position          int     `json:"position"`
+	Option1           string  `json:"option1"`
+	Option2           string  `json:"option2"`
+	Option3           string  `json:"option3"`
+	Grams             int     `json:"grams"`
+	Weight            float64 `json:"weight"`
+	WeightUnit        string  `json:"weight_unit"`
+	ImageID           int64   `json:"image_id"`
+	InventoryItemID   int64   `json:"inventory_item_id"`
+	InventoryQuantity int     `json:"inventory_quantity"`
+}
+
+// Option is a product option such as Size or Color.
+type Option struct {
+	ID        int64    `json:"id"`
+	ProductID int64    `json:"product_id"`
+	Name      string   `json:"name"`
+	Position  int      `json:"position"`
+	Values    []string `json:"values"`
+}
+
+// Image is a product image, optionally attached to some variants.
+type Image struct {
+	ID         int64   `json:"id"`
+	ProductID  int64   `json:"product_id"`
+	Position   int     `json:"position"`
+	Alt        string  `json:"alt"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Src        string  `json:"src"`
+	VariantIDs []int64 `json:"variant_ids"`
+}
+
+// MetafieldsResponse is the body of GET /products/{id}/metafields.json.
+type MetafieldsResponse struct {
+	Metafields []Metafield `json:"metafields"`
+}
+
+// Metafield is a product metafield. Value is kept raw because its JSON
+// type depends on the metafield type.
+type Metafield struct {
+	ID            int64           `json:"id"`
+	Namespace     string          `json:"namespace"`
+	Key           string          `json:"key"`
+	Type          string          `json:"type"`
+	Value         json.RawMessage `json:"value"`
+	OwnerID       int64           `json:"owner_id"`
+	OwnerResource string          `json:"owner_resource"`
+}
+
+// StringValue returns the value as text: JSON strings are unquoted, any other
+// JSON value is returned as is.
+func (m Metafield) StringValue() string {
+	var s string
+	if err := json.Unmarshal(m.Value, &s); err == nil {
+		return s
+	}
+	return string(m.Value)
+}
+
+// BoolValue reads a boolean metafield, which the API may return either as a
+// JSON boolean or as the string "true"/"false".
+func (m Metafield) BoolValue() (bool, error) {
+	var b bool
+	if err := json.Unmarshal(m.Value, &b); err == nil {
+		return b, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(m.Value, &s); err != nil {
+		return false, fmt.Errorf("metafield %s.%s is not a boolean", m.Namespace, m.Key)
+	}
+	return strconv.ParseBool(s)
+}
+
+// GetProduct fetches a product with its variants, options and images.
+func (c *Client) GetProduct(ctx context.Context, productID int64) (*Product, error) {
+	var response ProductResponse
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/products/%d.json", productID), nil, &response); err != nil {
+		return nil, err
+	}
+	return &response.Product, nil
+}
+
+// GetProductMetafields fetches every metafield attached to a product,
+// following pagination.
+func (c *Client) GetProductMetafields(ctx context.Context, productID int64) ([]Metafield, error) {
+	metafields := []Metafield{}
+	path := fmt.Sprintf("/products/%d/metafields.json?limit=250", productID)
+
+	for {
+		var response MetafieldsResponse
+		header, err := c.send(ctx, http.MethodGet, path, nil, &response)
+		if err != nil {
+			return nil, err
+		}
+		metafields = append(metafields, response.Metafields...)
+
+		pageInfo := nextPageInfo(header)
+		if pageInfo == "" {
+			return metafields, nil
+		}
+		path = fmt.Sprintf("/products/%d/metafields.json?limit=250&page_info=%s", productID, url.QueryEscape(pageInfo))
+	}
+}
+
+// OptionValue returns the variant's value for the option at the given
+// position (1 to 3).
+func (v Variant) OptionValue(position int) string {
+	switch position {
+	case 1:
+		return v.Option1
+	case 2:
+		return v.Option2
+	case 3:
+		return v.Option3
+	}
+	return ""
+}
+
+// HasOnlyDefaultVariant reports whether the product has no real options, i.e.
+// only Shopify's implicit "Title: Default Title" variant.
+func (p Product) HasOnlyDefaultVariant() bool {
+	if len(p.Variants) != 1 {
+		return false
+	}
+	return len(p.Options) == 0 ||
+		len(p.Options) == 1 && p.Options[0].Name == "Title" && p.Variants[0].Option1 == "Default Title"
+}
@@ -0,0 +1,78 @@
+package shopify
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// callLimitHeader reports REST bucket usage as "used/limit", e.g. "32/40".
+	callLimitHeader = "X-Shopify-Shop-Api-Call-Limit"
+
+	// leakRate is how many calls per second drain from the standard REST bucket.
+	leakRate = 2.0
+
+	// throttleThreshold is the bucket fill ratio above which requests are paced.
+	throttleThreshold = 0.8
+)
+
+// callLimitTransport paces requests once the REST leaky bucket is nearly full,
+// so bursts of calls slow down instead of running into 429s.
+type callLimitTransport struct {
+	base http.RoundTripper
+
+	mu         sync.Mutex
+	pauseUntil time.Time
+}
+
+func (t *callLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	wait := time.Until(t.pauseUntil)
+	t.mu.Unlock()
+
+	if wait > 0 {
+		log.Printf("⏳ Shopify call limit nearly reached, waiting %s\n", wait.Round(time.Millisecond))
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if used, limit, ok := parseCallLimit(resp.Header.Get(callLimitHeader)); ok {
+		excess := float64(used) - throttleThreshold*float64(limit)
+		if excess > 0 {
+			t.mu.Lock()
+			t.pauseUntil = time.Now().Add(time.Duration(excess / leakRate * float64(time.Second)))
+			t.mu.Unlock()
+		}
+	}
+	return resp, nil
+}
+
+func parseCallLimit(value string) (used, limit int, ok bool) {
+	usedStr, limitStr, found := strings.Cut(value, "/")
+	if !found {
+		return 0, 0, false
+	}
+	used, err := strconv.Atoi(strings.TrimSpace(usedStr))
+	if err != nil {
+		return 0, 0, false
+	}
+	limit, err = strconv.Atoi(strings.TrimSpace(limitStr))
+	if err != nil || limit <= 0 {
+		return 0, 0, false
+	}
+	return used, limit, true
+}
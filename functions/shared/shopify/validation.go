@@ -0,0 +1,189 @@
+package shopify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FieldError describes a single invalid field in a payload.
+type FieldError struct {
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+}
+
+// ValidationError lists every invalid field found in a payload.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		problems = append(problems, fmt.Sprintf("%s: %s", f.Field, f.Problem))
+	}
+	return "invalid payload: " + strings.Join(problems, "; ")
+}
+
+func (e *ValidationError) add(field, problem string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Problem: problem})
+}
+
+func (e *ValidationError) orNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Decode unmarshals body into v, turning type mismatches into a
+// ValidationError that names every offending field, not just the first one
+// encoding/json reports.
+func Decode(body []byte, v interface{}) error {
+	err := json.Unmarshal(body, v)
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		verr := &ValidationError{}
+		collectTypeErrors(body, reflect.TypeOf(v), "", verr)
+		if len(verr.Fields) == 0 {
+			verr.add(typeErr.Field, fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value))
+		}
+		return verr
+	}
+	return fmt.Errorf("error unmarshalling payload: %w", err)
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// collectTypeErrors walks raw alongside t and records every value that does
+// not fit its Go type.
+func collectTypeErrors(raw json.RawMessage, t reflect.Type, path string, verr *ValidationError) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if string(raw) == "null" || t.Kind() == reflect.Interface || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(raw, &fields) != nil {
+			verr.add(path, fmt.Sprintf("expected object, got %s", jsonKind(raw)))
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			for key, value := range fields {
+				if strings.EqualFold(key, name) {
+					collectTypeErrors(value, field.Type, joinPath(path, name), verr)
+					break
+				}
+			}
+		}
+		return
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		var items []json.RawMessage
+		if json.Unmarshal(raw, &items) != nil {
+			verr.add(path, fmt.Sprintf("expected array, got %s", jsonKind(raw)))
+			return
+		}
+		for i, item := range items {
+			collectTypeErrors(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), verr)
+		}
+		return
+
+	case reflect.Map:
+		var entries map[string]json.RawMessage
+		if json.Unmarshal(raw, &entries) != nil {
+			verr.add(path, fmt.Sprintf("expected object, got %s", jsonKind(raw)))
+			return
+		}
+		for _, key := range slices.Sorted(maps.Keys(entries)) {
+			collectTypeErrors(entries[key], t.Elem(), joinPath(path, key), verr)
+		}
+		return
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if err := json.Unmarshal(raw, reflect.New(t).Interface()); errors.As(err, &typeErr) {
+		verr.add(path, fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value))
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// jsonKind names the kind of a JSON value the way UnmarshalTypeError does.
+func jsonKind(raw json.RawMessage) string {
+	switch strings.TrimSpace(string(raw))[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "bool"
+	}
+	return "number"
+}
+
+// Validate checks the fields the Magento sync depends on.
+func (p Product) Validate() error {
+	verr := &ValidationError{}
+
+	if p.ID <= 0 {
+		verr.add("product.id", "is required")
+	}
+	if p.Handle == "" {
+		verr.add("product.handle", "is required")
+	}
+	if p.Title == "" {
+		verr.add("product.title", "is required")
+	}
+	if len(p.Variants) == 0 {
+		verr.add("product.variants", "must contain at least one variant")
+	}
+
+	for i, v := range p.Variants {
+		field := fmt.Sprintf("product.variants[%d]", i)
+		if v.ID <= 0 {
+			verr.add(field+".id", "is required")
+		}
+		if _, err := strconv.ParseFloat(v.Price, 64); err != nil {
+			verr.add(field+".price", fmt.Sprintf("must be a decimal string, got %q", v.Price))
+		}
+		if v.CompareAtPrice != "" {
+			if _, err := strconv.ParseFloat(v.CompareAtPrice, 64); err != nil {
+				verr.add(field+".compare_at_price", fmt.Sprintf("must be a decimal string, got %q", v.CompareAtPrice))
+			}
+		}
+	}
+
+	return verr.orNil()
+}
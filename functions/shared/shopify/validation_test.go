@@ -0,0 +1,91 @@
+package shopify
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeReportsEveryBadField(t *testing.T) {
+	body := `{
+		"id": "not-a-number",
+		"title": 42,
+		"handle": "carry-on",
+		"variants": [
+			{"id": 1, "price": "10.00", "grams": "heavy"},
+			{"id": "two", "price": "12.00"}
+		]
+	}`
+
+	var product Product
+	err := Decode([]byte(body), &product)
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Decode() error = %v, want *ValidationError", err)
+	}
+
+	var got []string
+	for _, f := range verr.Fields {
+		got = append(got, f.Field)
+	}
+	want := []string{"id", "title", "variants[0].grams", "variants[1].id"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantFields int
+		wantErr    bool
+	}{
+		{"valid", `{"id": 1, "title": "Carry-on", "variants": [{"id": 2}]}`, 0, false},
+		{"null fields", `{"id": 1, "image": null, "variants": null}`, 0, false},
+		{"variants not an array", `{"id": 1, "variants": {"id": 2}}`, 1, true},
+		{"malformed json", `{"id": `, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var product Product
+			err := Decode([]byte(tt.body), &product)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var verr *ValidationError
+			if errors.As(err, &verr) && len(verr.Fields) != tt.wantFields {
+				t.Errorf("fields = %v, want %d", verr.Fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestProductValidate(t *testing.T) {
+	product := Product{
+		ID:       1,
+		Title:    "Carry-on",
+		Variants: []Variant{{ID: 2, Price: "abc"}, {Price: "1.00", CompareAtPrice: "x"}},
+	}
+
+	var verr *ValidationError
+	if !errors.As(product.Validate(), &verr) {
+		t.Fatal("Validate() returned no ValidationError")
+	}
+
+	var got []string
+	for _, f := range verr.Fields {
+		got = append(got, f.Field)
+	}
+	want := []string{
+		"product.handle",
+		"product.variants[0].price",
+		"product.variants[1].id",
+		"product.variants[1].compare_at_price",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
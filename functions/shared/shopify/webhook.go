@@ -0,0 +1,59 @@
+package shopify
+
+// ParseProductWebhook decodes a products/* webhook body, which must at least
+// carry the product ID.
+func ParseProductWebhook(body []byte) (Product, error) {
+	var product Product
+	if err := Decode(body, &product); err != nil {
+		return product, err
+	}
+
+	verr := &ValidationError{}
+	if product.ID <= 0 {
+		verr.add("id", "is required")
+	}
+	return product, verr.orNil()
+}
+
+// InventoryLevelWebhook is the body of an inventory_levels/update webhook.
+type InventoryLevelWebhook struct {
+	InventoryItemID int64  `json:"inventory_item_id"`
+	LocationID      int64  `json:"location_id"`
+	Available       *int   `json:"available"`
+	UpdatedAt       string `json:"updated_at"`
+}
+
+// ParseInventoryLevelWebhook decodes an inventory_levels/update webhook body.
+func ParseInventoryLevelWebhook(body []byte) (InventoryLevelWebhook, error) {
+	var level InventoryLevelWebhook
+	if err := Decode(body, &level); err != nil {
+		return level, err
+	}
+
+	verr := &ValidationError{}
+	if level.InventoryItemID <= 0 {
+		verr.add("inventory_item_id", "is required")
+	}
+	return level, verr.orNil()
+}
+
+// InventoryItemWebhook is the body of an inventory_items/update webhook.
+type InventoryItemWebhook struct {
+	ID      int64  `json:"id"`
+	SKU     string `json:"sku"`
+	Tracked bool   `json:"tracked"`
+}
+
+// ParseInventoryItemWebhook decodes an inventory_items/update webhook body.
+func ParseInventoryItemWebhook(body []byte) (InventoryItemWebhook, error) {
+	var item InventoryItemWebhook
+	if err := Decode(body, &item); err != nil {
+		return item, err
+	}
+
+	verr := &ValidationError{}
+	if item.ID <= 0 {
+		verr.add("id", "is required")
+	}
+	return item, verr.orNil()
+}
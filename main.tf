@@ -1,5 +1,40 @@
 locals {
   lambda_functions = ["productHandler", "orderHandler"]
+
+  lambda_environment = {
+    BASE_URL      = var.base_url
+    URL_TOKEN     = var.url_token
+    STORE_NAME    = var.store_name
+    SHOPIFY_TOKEN = var.shopify_token
+
+    SHOPIFY_API_VERSION    = var.shopify_api_version
+    SHOPIFY_WEBHOOK_SECRET = var.shopify_webhook_secret
+    ORDER_AUTH_MODE        = var.order_auth_mode
+    ORDER_AUTH_TOKEN       = var.order_auth_token
+    ORDER_HMAC_SECRET      = var.order_hmac_secret
+
+    PRODUCT_DELETE_MODE          = var.product_delete_mode
+    PRODUCT_UNPUBLISH_MODE       = var.product_unpublish_mode
+    MAGENTO_SHOPIFY_ID_ATTRIBUTE = var.magento_shopify_id_attribute
+    MAGENTO_PROVISION_ATTRIBUTES = var.magento_provision_attributes
+    STOCK_SYNC_MODE              = var.stock_sync_mode
+    MAGENTO_SOURCE_MAP           = var.magento_source_map
+    PRODUCT_MODE                 = var.product_mode
+    MAGENTO_OPTION_ATTRIBUTES    = var.magento_option_attributes
+    SYNC_IMAGES                  = var.sync_images
+    FIELD_MAPPING_PATH           = var.field_mapping_path
+    MAGENTO_WEIGHT_UNIT          = var.magento_weight_unit
+    MAGENTO_STORE_VIEWS          = var.magento_store_views
+    SKU_STRATEGY                 = var.sku_strategy
+    SKU_PREVIOUS_STRATEGY        = var.sku_previous_strategy
+    MAPPING_STORE                = var.mapping_store
+    MAPPING_TABLE                = aws_dynamodb_table.sync_mapping.name
+    DEDUP_STORE                  = var.dedup_store
+    DEDUP_TABLE                  = aws_dynamodb_table.webhook_dedup.name
+    DEDUP_TTL                    = var.dedup_ttl
+    SYNC_MODE                    = var.sync_mode
+    SYNC_QUEUE_URL               = aws_sqs_queue.product_sync.url
+  }
 }
 
 # ============================= S3 BUCKET ============================
@@ -54,6 +89,55 @@ resource "aws_dynamodb_table" "mokobara_lock" {
   }
 }
 
+resource "aws_dynamodb_table" "sync_mapping" {
+  name         = "mokobara-sync-mapping"
+  hash_key     = "pk"
+  billing_mode = "PAY_PER_REQUEST"
+
+  attribute {
+    name = "pk"
+    type = "S"
+  }
+
+  attribute {
+    name = "shopify_product_id"
+    type = "N"
+  }
+
+  # Lets products/delete webhooks find every SKU synced from a product.
+  global_secondary_index {
+    name            = "shopify_product_id-index"
+    hash_key        = "shopify_product_id"
+    projection_type = "ALL"
+  }
+
+  tags = {
+    Name        = "Shopify Magento Mapping Table"
+    Environment = "Dev"
+  }
+}
+
+resource "aws_dynamodb_table" "webhook_dedup" {
+  name         = "mokobara-webhook-dedup"
+  hash_key     = "pk"
+  billing_mode = "PAY_PER_REQUEST"
+
+  attribute {
+    name = "pk"
+    type = "S"
+  }
+
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+
+  tags = {
+    Name        = "Shopify Webhook Dedup Table"
+    Environment = "Dev"
+  }
+}
+
 terraform {
   backend "s3" {
     bucket         = "mokobara-state-bucket"
@@ -152,6 +236,37 @@ resource "aws_iam_policy" "iam_policy_for_lambda" {
       ],
       "Resource": "arn:aws:logs:*:*:*",
       "Effect": "Allow"
+    },
+    {
+      "Action": [
+        "dynamodb:GetItem",
+        "dynamodb:UpdateItem",
+        "dynamodb:DeleteItem",
+        "dynamodb:Query"
+      ],
+      "Resource": [
+        "${aws_dynamodb_table.sync_mapping.arn}",
+        "${aws_dynamodb_table.sync_mapping.arn}/index/*"
+      ],
+      "Effect": "Allow"
+    },
+    {
+      "Action": [
+        "dynamodb:PutItem",
+        "dynamodb:DeleteItem"
+      ],
+      "Resource": "${aws_dynamodb_table.webhook_dedup.arn}",
+      "Effect": "Allow"
+    },
+    {
+      "Action": [
+        "sqs:SendMessage",
+        "sqs:ReceiveMessage",
+        "sqs:DeleteMessage",
+        "sqs:GetQueueAttributes"
+      ],
+      "Resource": "${aws_sqs_queue.product_sync.arn}",
+      "Effect": "Allow"
     }
  ]
 }
@@ -187,15 +302,53 @@ resource "aws_lambda_function" "lambda_function" {
   source_code_hash = data.archive_file.zip_the_lambda_code[each.key].output_base64sha256
 
   environment {
-    variables = {
-      BASE_URL      = var.base_url
-      URL_TOKEN     = var.url_token
-      STORE_NAME    = var.store_name
-      SHOPIFY_TOKEN = var.shopify_token
-    }
+    variables = local.lambda_environment
   }
 
   lifecycle {
     create_before_destroy = true
   }
 }
+
+# ======================= PRODUCT SYNC QUEUE =======================
+resource "aws_sqs_queue" "product_sync_dlq" {
+  name                      = "mokobara-product-sync-dlq"
+  message_retention_seconds = 1209600
+}
+
+resource "aws_sqs_queue" "product_sync" {
+  name                       = "mokobara-product-sync"
+  visibility_timeout_seconds = 1080
+
+  # Messages the worker returns unstarted near its deadline also count as
+  # receives, hence the headroom over the batch size.
+  redrive_policy = jsonencode({
+    deadLetterTargetArn = aws_sqs_queue.product_sync_dlq.arn
+    maxReceiveCount     = 10
+  })
+}
+
+resource "aws_lambda_function" "product_sync_worker" {
+  function_name = "productSyncWorker"
+  handler       = "bootstrap"
+  runtime       = "provided.al2023"
+  filename      = data.archive_file.zip_the_lambda_code["productHandler"].output_path
+  role          = aws_iam_role.lambda_execution_role.arn
+  timeout       = 180
+
+  architectures    = ["x86_64"]
+  source_code_hash = data.archive_file.zip_the_lambda_code["productHandler"].output_base64sha256
+
+  environment {
+    variables = merge(local.lambda_environment, {
+      FUNCTION_ROLE = "worker"
+    })
+  }
+}
+
+resource "aws_lambda_event_source_mapping" "product_sync_worker" {
+  event_source_arn        = aws_sqs_queue.product_sync.arn
+  function_name           = aws_lambda_function.product_sync_worker.arn
+  batch_size              = 5
+  function_response_types = ["ReportBatchItemFailures"]
+}
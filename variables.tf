@@ -16,4 +16,148 @@ variable "store_name" {
 variable "shopify_token" {
   description = "Name of the project"
   type        = string
+}
+
+variable "shopify_webhook_secret" {
+  description = "Shared secret used to verify Shopify webhook signatures"
+  type        = string
+  sensitive   = true
+}
+
+variable "order_auth_mode" {
+  description = "How inbound Magento order pushes are authenticated: bearer or hmac"
+  type        = string
+  default     = "bearer"
+}
+
+variable "order_auth_token" {
+  description = "Shared bearer token for inbound Magento order pushes"
+  type        = string
+  sensitive   = true
+  default     = ""
+}
+
+variable "order_hmac_secret" {
+  description = "Shared secret for HMAC-signed Magento order pushes"
+  type        = string
+  sensitive   = true
+  default     = ""
+}
+
+variable "shopify_api_version" {
+  description = "Shopify Admin API version used by both functions, e.g. 2025-07. Required, since Shopify retires versions about a year after release"
+  type        = string
+
+  validation {
+    condition     = can(regex("^\\d{4}-\\d{2}$", var.shopify_api_version))
+    error_message = "shopify_api_version must look like 2025-07."
+  }
+}
+
+variable "product_delete_mode" {
+  description = "What happens in Magento when a product is deleted in Shopify: disable or delete"
+  type        = string
+  default     = "disable"
+}
+
+variable "magento_shopify_id_attribute" {
+  description = "Magento product attribute that stores the Shopify product ID. It must exist in the attribute set of synced products; see magento_provision_attributes"
+  type        = string
+  default     = "shopify_product_id"
+}
+
+variable "magento_provision_attributes" {
+  description = "Set to \"true\" to create the Shopify ID attribute in Magento once per cold start; leave off once it exists"
+  type        = string
+  default     = "false"
+}
+
+variable "product_unpublish_mode" {
+  description = "How unpublished Shopify products are removed from the Magento storefront: disable or hide"
+  type        = string
+  default     = "disable"
+}
+
+variable "stock_sync_mode" {
+  description = "How stock is written to Magento: legacy (stockItems) or msi (source-items)"
+  type        = string
+  default     = "legacy"
+}
+
+variable "magento_source_map" {
+  description = "Shopify location ID to Magento MSI source code mapping, as locationID=source_code,...; stock at unmapped locations is not synced"
+  type        = string
+  default     = ""
+}
+
+variable "product_mode" {
+  description = "How Shopify variants are created in Magento: simple or configurable"
+  type        = string
+  default     = "simple"
+}
+
+variable "magento_option_attributes" {
+  description = "Shopify option name to Magento super attribute mapping, as Size=size,Color=color"
+  type        = string
+  default     = ""
+}
+
+variable "sync_images" {
+  description = "Whether Shopify product images are copied to the Magento media gallery"
+  type        = string
+  default     = "true"
+}
+
+variable "field_mapping_path" {
+  description = "Path of a Shopify to Magento field mapping file overriding the bundled mapping.json"
+  type        = string
+  default     = ""
+}
+
+variable "magento_weight_unit" {
+  description = "Weight unit configured in the Magento store that Shopify weights are converted to: kgs or lbs"
+  type        = string
+  default     = "kgs"
+}
+
+variable "magento_store_views" {
+  description = "JSON list of Magento store views with their own prices and translations, e.g. [{\"code\": \"ae\", \"country\": \"AE\", \"locale\": \"ar\"}]"
+  type        = string
+  default     = ""
+}
+
+variable "sku_strategy" {
+  description = "How Magento SKUs are derived from Shopify variants: raw, handle_sku or variant_id"
+  type        = string
+  default     = "handle_sku"
+}
+
+variable "sku_previous_strategy" {
+  description = "SKU strategy used before the current one; products under its SKUs are renamed on their next sync"
+  type        = string
+  default     = ""
+}
+
+variable "mapping_store" {
+  description = "Where Shopify to Magento ID mappings are kept: dynamodb or memory"
+  type        = string
+  default     = "dynamodb"
+}
+
+variable "dedup_store" {
+  description = "Where processed Shopify webhook IDs are remembered: dynamodb or memory"
+  type        = string
+  default     = "dynamodb"
+}
+
+variable "dedup_ttl" {
+  description = "How long processed Shopify webhooks are remembered, as a Go duration such as 24h"
+  type        = string
+  default     = "24h"
+}
+
+variable "sync_mode" {
+  description = "Whether product webhooks are synced before answering Shopify (sync) or queued for the worker (async)"
+  type        = string
+  default     = "sync"
 }
\ No newline at end of file